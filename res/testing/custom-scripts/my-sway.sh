@@ -0,0 +1,5 @@
+#!/bin/sh
+# Name: My Sway
+# Environment: wayland
+# DesktopNames: sway;custom
+exec sway --unsupported-gpu
@@ -0,0 +1,2 @@
+#!/bin/sh
+# Name: Not executable
@@ -0,0 +1,4 @@
+# Testing .profile. Only for test purpose!
+echo "Welcome from profile"
+export EMPTTY_PROFILE="loaded from profile"
+export HOME=/tmp/hijacked
@@ -0,0 +1,2 @@
+# Testing .profile with slow start. Only for test purpose!
+sleep 10
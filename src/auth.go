@@ -5,10 +5,15 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 )
 
 const (
 	pathLastLoggedInUser = "/var/cache/emptty/lastuser"
+	pathNologin          = "/etc/nologin"
+	pathShells           = "/etc/shells"
+	pathSystemMotd       = "/etc/motd"
+	pathHushLogin        = "/.hushlogin"
 
 	constEnSelectLastUserFalse  = "false"
 	constEnSelectLastUserPerTTy = "per-tty"
@@ -30,12 +35,17 @@ const (
 
 type authBase struct {
 	command string
+	session string
 }
 
 func (a *authBase) getCommand() string {
 	return a.command
 }
 
+func (a *authBase) getSession() string {
+	return a.session
+}
+
 // Performs input selection user. If saving last user is enabled (PerTty/Global), user is read from defined path and used as predefined value.
 func (a *authBase) selectUser(c *config) (string, error) {
 	if c.DefaultUser != "" {
@@ -66,12 +76,40 @@ func (a *authBase) selectUser(c *config) (string, error) {
 		return "", nil
 	}
 
+	username, a.session = splitLoginSession(username, c.LoginSessionSep)
+
 	if lastUser != "" && username == "" {
 		username = lastUser
 	}
 	return username, nil
 }
 
+// Splits login input into username and session, if separator is defined and found.
+func splitLoginSession(input, separator string) (string, string) {
+	if separator != "" {
+		if i := strings.Index(input, separator); i > -1 {
+			return input[:i], strings.TrimSpace(input[i+len(separator):])
+		}
+	}
+	return input, ""
+}
+
+// Handles failed login attempt and returns true, if another attempt is allowed by LOGIN_RETRIES.
+func (a *authBase) allowNextAttempt(c *config, attempt int) bool {
+	if c.Autologin || attempt >= c.Defs.getLoginRetries() {
+		return false
+	}
+	fmt.Printf("\n%sLogin incorrect\n\n", c.GetIndentString())
+	return true
+}
+
+// Waits for FAIL_DELAY after failed login attempt.
+func (a *authBase) failDelay(c *config) {
+	if delay := c.Defs.getFailDelay(); delay > 0 {
+		time.Sleep(time.Duration(delay) * time.Second)
+	}
+}
+
 // Gets last selected user with respect to configuration.
 func (a *authBase) getLastSelectedUser(c *config) string {
 	switch c.SelectLastUser {
@@ -117,3 +155,35 @@ func (a *authBase) saveLastSelectedUser(c *config, username string) {
 		logPrint(err)
 	}
 }
+
+// Reads content of nologin file on path. If file does not exist, it returns false.
+func readNologin(path string) (string, bool) {
+	if !fileExists(path) {
+		return "", false
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		logPrint(err)
+	}
+	return strings.TrimSpace(string(b)), true
+}
+
+// Checks, if shell is listed in shells file. If file does not exist, only default shells are allowed.
+func isValidShell(shell, shellsPath string) bool {
+	b, err := os.ReadFile(shellsPath)
+	if err != nil {
+		return shell == "/bin/sh" || shell == "/bin/csh"
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") && line == shell {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks, if user has hushlogin file in home directory.
+func isHushLogin(usr *sysuser) bool {
+	return fileExists(usr.homedir + pathHushLogin)
+}
@@ -16,11 +16,9 @@ type nopamHandle struct {
 	u *sysuser
 }
 
-// Creates authHandle and handles authorization
-func auth(conf *config) *nopamHandle {
-	h := &nopamHandle{authBase: &authBase{}}
-	h.authUser(conf)
-	return h
+// Creates authHandle, that is authorized by authUser
+func newAuth() *nopamHandle {
+	return &nopamHandle{authBase: &authBase{}}
 }
 
 // Handle authentication of user without PAM.
@@ -32,35 +30,68 @@ func (n *nopamHandle) authUser(conf *config) {
 		usr, err := user.Lookup(conf.DefaultUser)
 		handleErr(err)
 		n.u = getSysuser(usr)
+		n.checkAccount(conf)
+		handleErr(checkLoginTimeLimit(n.u.username))
 		return
 	}
 
-	username, err := n.selectUser(conf)
-	handleErr(err)
-	if n.command != "" {
-		return
-	}
+	for attempt := 1; ; attempt++ {
+		username, err := n.selectUser(conf)
+		handleErr(err)
+		if n.command != "" {
+			return
+		}
 
-	if !conf.HideEnterPassword {
-		fmt.Print(conf.GetIndentString() + "Password: ")
-	}
-	password, err := readPassword()
-	handleErr(err)
+		if !conf.HideEnterPassword {
+			fmt.Print(conf.GetIndentString() + "Password: ")
+		}
+		password, err := readPassword()
+		handleErr(err)
 
-	if n.authPassword(username, password) {
-		n.saveLastSelectedUser(conf, username)
-		usr, err := user.Lookup(username)
-		username = ""
+		if n.authPassword(username, password) {
+			n.saveLastSelectedUser(conf, username)
+			usr, err := user.Lookup(username)
+			username = ""
 
-		handleErr(err)
+			handleErr(err)
 
-		n.u = getSysuser(usr)
-		return
+			n.u = getSysuser(usr)
+			n.checkAccount(conf)
+			handleErr(checkLoginTimeLimit(n.u.username))
+			return
+		}
+		addBtmpEntry(username, os.Getpid(), conf.strTTY())
+		n.failDelay(conf)
+
+		if !n.allowNextAttempt(conf, attempt) {
+			break
+		}
 	}
-	addBtmpEntry(username, os.Getpid(), conf.strTTY())
 	handleStrErr("Authentication failure")
 }
 
+// Checks account restrictions, that are otherwise handled by pam_nologin, pam_shells and pam_motd.
+func (n *nopamHandle) checkAccount(conf *config) {
+	if n.u.uid != 0 {
+		if msg, exists := readNologin(pathNologin); exists {
+			if msg != "" {
+				fmt.Println(msg)
+			}
+			handleStrErr("Logins are not allowed")
+		}
+	}
+
+	if conf.CheckShells && !isValidShell(n.u.getShell(), pathShells) {
+		handleStrErr("Shell of user is not allowed")
+	}
+
+	if !isHushLogin(n.u) {
+		if motd, err := os.ReadFile(pathSystemMotd); err == nil && len(motd) > 0 {
+			fmt.Print(string(motd))
+		}
+	}
+}
+
 // Gets sysuser
 func (n *nopamHandle) usr() *sysuser {
 	return n.u
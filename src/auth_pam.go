@@ -31,11 +31,9 @@ type pamHandle struct {
 	pamState
 }
 
-// Creates authHandle and handles authorization
-func auth(conf *config) *pamHandle {
-	h := &pamHandle{authBase: &authBase{}}
-	h.authUser(conf)
-	return h
+// Creates authHandle, that is authorized by authUser
+func newAuth() *pamHandle {
+	return &pamHandle{authBase: &authBase{}}
 }
 
 // Handle PAM authentication of user.
@@ -43,50 +41,60 @@ func auth(conf *config) *pamHandle {
 //
 // If autologin is enabled, it behaves as user has been authorized.
 func (h *pamHandle) authUser(conf *config) {
-	username, err := h.selectUser(conf)
-	handleErr(err)
-	if h.command != "" {
-		return
-	}
+	for attempt := 1; ; attempt++ {
+		username, err := h.selectUser(conf)
+		handleErr(err)
+		if h.command != "" {
+			return
+		}
 
-	h.pamState = pamInit
-	h.trans, _ = pam.StartFunc("emptty", username, func(s pam.Style, msg string) (string, error) {
-		switch s {
-		case pam.PromptEchoOff:
-			if conf.Autologin {
-				break
+		h.pamState = pamInit
+		h.trans, _ = pam.StartFunc("emptty", username, func(s pam.Style, msg string) (string, error) {
+			switch s {
+			case pam.PromptEchoOff:
+				if conf.Autologin {
+					break
+				}
+				if !conf.HideEnterPassword {
+					fmt.Print(conf.GetIndentString() + "Password: ")
+				}
+				return readPassword()
+			case pam.PromptEchoOn:
+				return "", nil
+			case pam.ErrorMsg:
+				logPrint(msg)
+				return "", nil
+			case pam.TextInfo:
+				fmt.Println(msg)
+				return "", nil
 			}
-			if !conf.HideEnterPassword {
-				fmt.Print(conf.GetIndentString() + "Password: ")
+			return "", errors.New("unrecognized message style")
+		})
+
+		if err := h.trans.Authenticate(pam.DisallowNullAuthtok); err != nil {
+			bkpErr := errors.New(err.Error())
+			username, _ := h.trans.GetItem(pam.User)
+			addBtmpEntry(username, os.Getpid(), conf.strTTY())
+
+			if h.allowNextAttempt(conf, attempt) {
+				h.closeAuth()
+				continue
 			}
-			return readPassword()
-		case pam.PromptEchoOn:
-			return "", nil
-		case pam.ErrorMsg:
-			logPrint(msg)
-			return "", nil
-		case pam.TextInfo:
-			fmt.Println(msg)
-			return "", nil
+			h.handleErr(bkpErr)
 		}
-		return "", errors.New("unrecognized message style")
-	})
-
-	if err := h.trans.Authenticate(pam.DisallowNullAuthtok); err != nil {
-		bkpErr := errors.New(err.Error())
-		username, _ := h.trans.GetItem(pam.User)
-		addBtmpEntry(username, os.Getpid(), conf.strTTY())
-		h.handleErr(bkpErr)
+		break
 	}
 	h.pamState = pamAuthenticated
 	logPrint("Authenticate OK")
 
 	h.handleErr(h.trans.AcctMgmt(pam.Silent))
+	pamUsr, _ := h.trans.GetItem(pam.User)
+	h.handleErr(checkLoginTimeLimit(pamUsr))
+
 	h.handleErr(h.trans.SetItem(pam.Tty, "tty"+conf.strTTY()))
 	h.handleErr(h.trans.SetCred(pam.EstablishCred))
 	h.pamState = pamCredsEstablished
 
-	pamUsr, _ := h.trans.GetItem(pam.User)
 	usr, _ := user.Lookup(pamUsr)
 
 	h.u = getSysuser(usr)
@@ -0,0 +1,93 @@
+package src
+
+import "testing"
+
+func TestReadNologin(t *testing.T) {
+	if _, exists := readNologin(getTestingPath("non-existing-nologin")); exists {
+		t.Error("TestReadNologin: nologin should not exist")
+	}
+
+	msg, exists := readNologin(getTestingPath("nologin"))
+	if !exists {
+		t.Error("TestReadNologin: nologin should exist")
+	}
+	if msg != "System is under maintenance." {
+		t.Errorf("TestReadNologin: unexpected message '%s'", msg)
+	}
+}
+
+func TestIsValidShell(t *testing.T) {
+	shellsPath := getTestingPath("shells")
+
+	if !isValidShell("/bin/bash", shellsPath) {
+		t.Error("TestIsValidShell: /bin/bash should be valid")
+	}
+
+	if isValidShell("/usr/bin/fish", shellsPath) {
+		t.Error("TestIsValidShell: /usr/bin/fish should not be valid")
+	}
+
+	if isValidShell("# /etc/shells: valid login shells", shellsPath) {
+		t.Error("TestIsValidShell: comment should not be valid shell")
+	}
+
+	if !isValidShell("/bin/sh", getTestingPath("non-existing-shells")) {
+		t.Error("TestIsValidShell: /bin/sh should be valid without shells file")
+	}
+
+	if isValidShell("/bin/bash", getTestingPath("non-existing-shells")) {
+		t.Error("TestIsValidShell: /bin/bash should not be valid without shells file")
+	}
+}
+
+func TestIsHushLogin(t *testing.T) {
+	if isHushLogin(&sysuser{homedir: getTestingPath("userHome")}) {
+		t.Error("TestIsHushLogin: userHome should not have hushlogin")
+	}
+
+	if !isHushLogin(&sysuser{homedir: getTestingPath("userHome4")}) {
+		t.Error("TestIsHushLogin: userHome4 should have hushlogin")
+	}
+}
+
+func TestAllowNextAttempt(t *testing.T) {
+	a := &authBase{}
+	c := &config{Defs: readLoginDefs(getTestingPath("login.defs"))}
+
+	readOutput(func() {
+		if !a.allowNextAttempt(c, 1) {
+			t.Error("TestAllowNextAttempt: next attempt should be allowed")
+		}
+
+		if a.allowNextAttempt(c, 5) {
+			t.Error("TestAllowNextAttempt: next attempt should not be allowed after LOGIN_RETRIES")
+		}
+
+		c.Autologin = true
+		if a.allowNextAttempt(c, 1) {
+			t.Error("TestAllowNextAttempt: next attempt should not be allowed for autologin")
+		}
+
+		if a.allowNextAttempt(&config{}, 1) {
+			t.Error("TestAllowNextAttempt: next attempt should not be allowed without login.defs")
+		}
+	})
+}
+
+func TestSplitLoginSession(t *testing.T) {
+	if username, session := splitLoginSession("alice/sway", "/"); username != "alice" || session != "sway" {
+		t.Error("TestSplitLoginSession: username and session should be split")
+	}
+
+	if username, session := splitLoginSession("alice::Plasma (X11)", "::"); username != "alice" || session != "Plasma (X11)" {
+		t.Error("TestSplitLoginSession: username and session should be split by longer separator")
+	}
+
+	if username, session := splitLoginSession("alice/sway", ""); username != "alice/sway" || session != "" {
+		t.Error("TestSplitLoginSession: input should not be split without separator")
+	}
+
+	if username, session := splitLoginSession("alice", "/"); username != "alice" || session != "" {
+		t.Error("TestSplitLoginSession: input without separator should be kept")
+	}
+}
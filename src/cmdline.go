@@ -0,0 +1,82 @@
+package src
+
+import (
+	"os"
+	"strings"
+)
+
+const (
+	pathKernelCmdline = "/proc/cmdline"
+	cmdlinePrefix     = "emptty."
+
+	cmdlineSession    = "session"
+	cmdlineSessionEnv = "session_env"
+	cmdlineAutologin  = "autologin"
+	cmdlineTty        = "tty"
+	cmdlineUser       = "user"
+)
+
+// Reads parameters with "emptty." prefix from kernel command line on path, prefix is removed from keys.
+func readKernelCmdline(path string) map[string]string {
+	result := make(map[string]string)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return result
+	}
+
+	for _, param := range parseExec(strings.TrimSpace(string(content))) {
+		param = strings.ReplaceAll(param, "\"", "")
+		key, value, found := strings.Cut(param, "=")
+		if found && strings.HasPrefix(key, cmdlinePrefix) && len(key) > len(cmdlinePrefix) {
+			result[key[len(cmdlinePrefix):]] = value
+		}
+	}
+	return result
+}
+
+// Applies parameters from kernel command line into configuration and returns applied and ignored parameters. Autologin could be
+// only disabled and user could be changed only if autologin is disabled or it is already configured for the same user,
+// so kernel command line could not be used to log in without password. TTY is applied only if allowTty is true, so instances
+// with TTY defined by argument stay on their TTY.
+func applyKernelCmdline(conf *config, params map[string]string, allowTty bool) (applied, ignored []string) {
+	for _, key := range []string{cmdlineSession, cmdlineSessionEnv, cmdlineAutologin, cmdlineTty, cmdlineUser} {
+		value, exists := params[key]
+		if !exists {
+			continue
+		}
+
+		switch key {
+		case cmdlineSession:
+			conf.DefaultSession = value
+			conf.AutologinSession = value
+		case cmdlineSessionEnv:
+			conf.DefaultSessionEnv = conf.ParseEnv(value, "")
+			conf.AutologinSessionEnv = conf.DefaultSessionEnv
+		case cmdlineAutologin:
+			if parseBool(value, constFalse) {
+				ignored = append(ignored, cmdlinePrefix+key+"="+value)
+				continue
+			}
+			conf.Autologin = false
+		case cmdlineTty:
+			tty := parseTTY(strings.TrimPrefix(value, "tty"), "0")
+			if tty <= 0 {
+				continue
+			}
+			if !allowTty {
+				ignored = append(ignored, cmdlinePrefix+key+"="+value)
+				continue
+			}
+			conf.Tty = tty
+		case cmdlineUser:
+			if conf.Autologin && conf.DefaultUser != value {
+				ignored = append(ignored, cmdlinePrefix+key+"="+value)
+				continue
+			}
+			conf.DefaultUser = value
+		}
+		applied = append(applied, cmdlinePrefix+key+"="+value)
+	}
+	return applied, ignored
+}
@@ -0,0 +1,67 @@
+package src
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadKernelCmdline(t *testing.T) {
+	params := readKernelCmdline(getTestingPath("cmdline"))
+	if len(params) != 6 || params["session"] != "Safe Desktop" || params["tty"] != "tty3" || params["unknown"] != "1" {
+		t.Errorf("TestReadKernelCmdline: unexpected parameters %v", params)
+	}
+
+	if params = readKernelCmdline("/dev/null/cmdline"); len(params) != 0 {
+		t.Error("TestReadKernelCmdline: missing file should not return any parameter")
+	}
+}
+
+func TestApplyKernelCmdline(t *testing.T) {
+	conf := &config{Tty: 7, Autologin: true, DefaultUser: "user", DefaultSession: "broken"}
+	applied, ignored := applyKernelCmdline(conf, readKernelCmdline(getTestingPath("cmdline")), true)
+
+	if conf.DefaultSession != "Safe Desktop" || conf.AutologinSession != "Safe Desktop" {
+		t.Error("TestApplyKernelCmdline: session was not overridden")
+	}
+	if conf.DefaultSessionEnv != Xorg || conf.AutologinSessionEnv != Xorg {
+		t.Error("TestApplyKernelCmdline: session environment was not overridden")
+	}
+	if conf.Autologin || conf.DefaultUser != "recovery" {
+		t.Error("TestApplyKernelCmdline: autologin or user was not overridden")
+	}
+	if conf.Tty != 3 {
+		t.Error("TestApplyKernelCmdline: tty was not overridden")
+	}
+	if len(applied) != 5 || len(ignored) != 0 || strings.Contains(strings.Join(applied, " "), "unknown") {
+		t.Errorf("TestApplyKernelCmdline: unexpected applied parameters %v", applied)
+	}
+
+	conf = &config{Tty: 7}
+	if applied, _ = applyKernelCmdline(conf, map[string]string{"tty": "invalid"}, true); len(applied) != 0 || conf.Tty != 7 {
+		t.Error("TestApplyKernelCmdline: invalid tty should be ignored")
+	}
+
+	applied, ignored = applyKernelCmdline(conf, map[string]string{"tty": "3"}, false)
+	if conf.Tty != 7 || len(applied) != 0 || len(ignored) != 1 {
+		t.Error("TestApplyKernelCmdline: tty should not be overridden, if it is defined by argument")
+	}
+}
+
+func TestApplyKernelCmdlineAutologin(t *testing.T) {
+	conf := &config{}
+	applied, ignored := applyKernelCmdline(conf, map[string]string{"autologin": "1", "user": "root"}, true)
+	if conf.Autologin || conf.DefaultUser != "root" || len(applied) != 1 || len(ignored) != 1 {
+		t.Error("TestApplyKernelCmdlineAutologin: autologin should not be enabled")
+	}
+
+	conf = &config{Autologin: true, DefaultUser: "kiosk"}
+	applied, ignored = applyKernelCmdline(conf, map[string]string{"user": "root"}, true)
+	if !conf.Autologin || conf.DefaultUser != "kiosk" || len(applied) != 0 || len(ignored) != 1 {
+		t.Error("TestApplyKernelCmdlineAutologin: user of autologin should not be changed")
+	}
+
+	applied, ignored = applyKernelCmdline(conf, map[string]string{"user": "kiosk"}, true)
+	if conf.DefaultUser != "kiosk" || len(applied) != 1 || len(ignored) != 0 {
+		t.Error("TestApplyKernelCmdlineAutologin: already configured user of autologin should be accepted")
+	}
+}
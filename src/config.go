@@ -34,6 +34,18 @@ type config struct {
 	HideEnterPassword   bool             `config:"HIDE_ENTER_PASSWORD" default:"false"`
 	AutoSelection       bool             `config:"AUTO_SELECTION" default:"false"`
 	AllowCommands       bool             `config:"ALLOW_COMMANDS" default:"true"`
+	CheckShells         bool             `config:"CHECK_SHELLS" default:"false"`
+	LoginDefs           bool             `config:"LOGIN_DEFS" default:"true"`
+	EnvClean            bool             `config:"ENV_CLEAN" default:"false"`
+	ImportShellEnv      bool             `config:"IMPORT_SHELL_ENV" default:"false"`
+	LastSessionPerTty   bool             `config:"LAST_SESSION_PER_TTY" default:"false"`
+	InteractiveSelect   bool             `config:"INTERACTIVE_SELECTION" default:"false"`
+	ConsoleSession      bool             `config:"CONSOLE_SESSION" default:"false"`
+	ImportShellEnvTime  int              `config:"IMPORT_SHELL_ENV_TIMEOUT" parser:"ParsePositiveInt" default:"5"`
+	EnvAllow            []string         `config:"ENV_ALLOW" parser:"ParseList" string:"StringList" default:""`
+	EnvDeny             []string         `config:"ENV_DENY" parser:"ParseList" string:"StringList" default:"NOTIFY_SOCKET,INVOCATION_ID,JOURNAL_STREAM,LISTEN_*,WATCHDOG_*,MANAGERPID,SYSTEMD_EXEC_PID"`
+	SessionOrder        []string         `config:"SESSION_ORDER" parser:"ParseList" string:"StringList" default:""`
+	HiddenSessions      []string         `config:"HIDDEN_SESSIONS" parser:"ParseList" string:"StringList" default:""`
 	DefaultEnv          enEnvironment    `config:"DEFAULT_ENV" parser:"ParseDefaultEnv" default:"" priority:"true"`
 	DefaultSessionEnv   enEnvironment    `config:"DEFAULT_SESSION_ENV" parser:"ParseEnv" default:""`
 	AutologinSessionEnv enEnvironment    `config:"AUTOLOGIN_SESSION_ENV" parser:"ParseEnv" default:""`
@@ -46,10 +58,16 @@ type config struct {
 	DefaultUser         string           `config:"DEFAULT_USER" default:""`
 	DefaultSession      string           `config:"DEFAULT_SESSION" default:""`
 	AutologinSession    string           `config:"AUTOLOGIN_SESSION" default:""`
+	LoginSessionSep     string           `config:"LOGIN_SESSION_SEPARATOR" default:""`
+	ConsoleCommand      string           `config:"CONSOLE_COMMAND" default:""`
 	Lang                string           `config:"LANG" default:""`
 	UserLang            string           ``
+	AsUser              string           ``
+	DryRun              bool             ``
 	LoggingFile         string           `config:"LOGGING_FILE" default:"/var/log/emptty/[TTY_NUMBER].log"`
 	XorgArgs            string           `config:"XORG_ARGS" default:""`
+	XorgBinary          string           `config:"XORG_BINARY" default:""`
+	FailsafeCommand     string           `config:"FAILSAFE_COMMAND" default:"xterm"`
 	DynamicMotdPath     string           `config:"DYNAMIC_MOTD_PATH" default:"/etc/emptty/motd-gen.sh"`
 	MotdPath            string           `config:"MOTD_PATH" default:"/etc/emptty/motd"`
 	FgColor             string           `config:"FG_COLOR" parser:"ConvertFgColor" string:"StringFgColor" default:""`
@@ -60,9 +78,17 @@ type config struct {
 	XorgSessionsPath    string           `config:"XORG_SESSIONS_PATH" default:"/usr/share/xsessions/"`
 	WaylandSessionsPath string           `config:"WAYLAND_SESSIONS_PATH" default:"/usr/share/wayland-sessions/"`
 	SelectLastUser      enSelectLastUser `config:"SELECT_LAST_USER" parser:"ParseSelectLastUser" string:"StringLastUser" default:"false"`
+	UnavailableSessions enUnavailable    `config:"UNAVAILABLE_SESSIONS" parser:"ParseUnavailableSessions" string:"StringUnavailableSessions" default:"hide"`
+	SessionSort         enSessionSort    `config:"SESSION_SORT" parser:"ParseSessionSort" string:"StringSessionSort" default:"name"`
+	SessionRestart      enSessionRestart `config:"SESSION_RESTART" parser:"ParseSessionRestart" string:"StringSessionRestart" default:"never"`
+	SessionRestartLimit int              `config:"SESSION_RESTART_LIMIT" default:"3"`
+	SessionRestartDelay int              `config:"SESSION_RESTART_DELAY" default:"2"`
+	TimeLimitWarnCmd    string           `config:"TIME_LIMIT_WARN_CMD" default:""`
+	TimeLimitWarnBefore int              `config:"TIME_LIMIT_WARN_BEFORE" default:"5"`
 	CmdPoweroff         string           `config:"CMD_POWEROFF" default:"poweroff"`
 	CmdReboot           string           `config:"CMD_REBOOT" default:"reboot"`
 	CmdSuspend          string           `config:"CMD_SUSPEND" default:""`
+	Defs                *loginDefs
 }
 
 var cfgWaitExitTimeout = -1
@@ -131,6 +157,12 @@ func loadConfig(path string) *config {
 		}
 	}
 
+	if c.LoginDefs {
+		c.Defs = readLoginDefs(pathLoginDefs)
+	} else {
+		c.Defs = newLoginDefs()
+	}
+
 	return &c
 }
 
@@ -206,6 +238,11 @@ func (c *config) ParseDefaultEnv(value, defaultValue string) enEnvironment {
 	return defaultEnvValue
 }
 
+// Parses comma separated list of values.
+func (c *config) ParseList(value, defaultValue string) []string {
+	return parseList(sanitizeValue(value, defaultValue))
+}
+
 // Coverts string foreground color name into ANSI color value.
 func (c *config) ConvertFgColor(value, defaultValue string) string {
 	return convertColor(sanitizeValue(value, defaultValue), true)
@@ -237,6 +274,21 @@ func (c *config) ParseSelectLastUser(value, defaultValue string) enSelectLastUse
 	return False
 }
 
+// Parses unavailable sessions config option.
+func (c *config) ParseUnavailableSessions(value, defaultValue string) enUnavailable {
+	return parseUnavailableSessions(value, defaultValue)
+}
+
+// Parses session sort config option.
+func (c *config) ParseSessionSort(value, defaultValue string) enSessionSort {
+	return parseSessionSort(value, defaultValue)
+}
+
+// Parses session restart config option.
+func (c *config) ParseSessionRestart(value, defaultValue string) enSessionRestart {
+	return parseSessionRestart(value, defaultValue)
+}
+
 func (c *config) printConfig() {
 	configType := reflect.TypeOf(*c)
 	configValue := reflect.ValueOf(*c)
@@ -286,6 +338,22 @@ func (c *config) StringLastUser(value enSelectLastUser) string {
 	return []string{constEnSelectLastUserFalse, constEnSelectLastUserPerTTy, constEnSelectLastUserGlobal}[int(value)]
 }
 
+func (c *config) StringUnavailableSessions(value enUnavailable) string {
+	return value.stringify()
+}
+
+func (c *config) StringSessionSort(value enSessionSort) string {
+	return value.stringify()
+}
+
+func (c *config) StringSessionRestart(value enSessionRestart) string {
+	return value.stringify()
+}
+
+func (c *config) StringList(value []string) string {
+	return strings.Join(value, ",")
+}
+
 func (c *config) StringFgColor(value string) string {
 	return stringColor(value, true)
 }
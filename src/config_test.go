@@ -180,6 +180,10 @@ func TestLoadConfig(t *testing.T) {
 	if conf.WaitExitTimeout != 3 || cfgWaitExitTimeout != 3 {
 		t.Error("TestLoadConfig: WAIT_EXIT_TIMEOUT value is not correct")
 	}
+
+	if conf.UnavailableSessions != UnavailableMark {
+		t.Error("TestLoadConfig: UNAVAILABLE_SESSIONS value is not correct")
+	}
 }
 
 func TestLangLoadConfig(t *testing.T) {
@@ -4,8 +4,10 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -23,17 +25,37 @@ const (
 	desktopNames       = "DESKTOPNAMES"
 	desktopNoDisplay   = "NODISPLAY"
 	desktopHidden      = "HIDDEN"
+	desktopIcon        = "ICON"
+	desktopTryExec     = "TRYEXEC"
+	desktopEnvPrefix   = "X-EMPTTY-ENV-"
+	desktopPreExec     = "X-EMPTTY-PREEXEC"
+	desktopPostExec    = "X-EMPTTY-POSTEXEC"
+	desktopXorgArgs    = "X-EMPTTY-XORGARGS"
+	desktopXServer     = "X-EMPTTY-XSERVER"
+	confEnvPrefix      = "ENV_"
 
 	constTrue  = "true"
 	constFalse = "false"
 	constAuto  = "auto"
 
+	constUnavailableHide = "hide"
+	constUnavailableMark = "mark"
+	constUnavailableShow = "show"
+
 	pathLastSession       = "/.cache/emptty/last-session"
+	lastSessionId         = "ID"
 	pathCustomSessions    = "/etc/emptty/custom-sessions/"
 	pathUserCustomSession = "/.config/emptty-custom-sessions/"
 
-	pathLocalWaylandSessions = "/.local/share/wayland-sessions/"
-	pathLocalXSessions       = "/.local/share/xsessions/"
+	constFailsafeId   = "failsafe"
+	constFailsafeName = "Failsafe"
+
+	constProfileIdPrefix = "profile:"
+
+	pathLocalShare      = "/.local/share"
+	pathWaylandSessions = "wayland-sessions/"
+	pathXSessions       = "xsessions/"
+	defaultXdgDataDirs  = "/usr/local/share:/usr/share"
 )
 
 type enSelection byte
@@ -50,10 +72,26 @@ const (
 	SelectionAuto
 )
 
+type enUnavailable byte
+
+const (
+
+	// Hide sessions, that could not be started
+	UnavailableHide enUnavailable = iota
+
+	// Mark sessions, that could not be started
+	UnavailableMark
+
+	// Show all sessions without any check
+	UnavailableShow
+)
+
 // desktop defines structure for display environments and window managers.
 type desktop struct {
+	id           string
 	name         string
 	exec         string
+	execArgs     []string
 	env          enEnvironment
 	envOrigin    enEnvironment
 	isUser       bool
@@ -64,6 +102,16 @@ type desktop struct {
 	desktopNames string
 	noDisplay    bool
 	hidden       bool
+	tryExec      string
+	unavailable  bool
+	envVars      map[string]string
+	preExec      []string
+	postExec     []string
+	xorgArgs     []string
+	xServer      string
+	failsafe     bool
+	lang         string
+	usage        *sessionStat
 }
 
 // Gets exec path from desktop and returns true, if command allows dbus-launch.
@@ -76,6 +124,46 @@ func (d *desktop) getStrExec() (string, bool) {
 	return d.path, false
 }
 
+// Gets exec arguments from desktop, desktop files provide already parsed Exec.
+func (d *desktop) getExecArgs() []string {
+	if d.selection != SelectionFalse && d.child != nil {
+		return append([]string{d.path}, d.child.getArgs()...)
+	} else if d.exec != "" {
+		return d.getArgs()
+	}
+	return []string{d.path}
+}
+
+// Gets parsed exec arguments, falls back to parsing of exec string.
+func (d *desktop) getArgs() []string {
+	if d.execArgs != nil {
+		return d.execArgs
+	}
+	return parseExec(d.exec)
+}
+
+// Checks, if TryExec and Exec binary of desktop could be found on path.
+func (d *desktop) isAvailable(path string) bool {
+	if d.tryExec != "" && !isExecutableInPath(d.tryExec, path) {
+		return false
+	}
+	if d.exec == "" {
+		return true
+	}
+	args := d.getArgs()
+	return len(args) == 0 || strings.Contains(args[0], "$") || isExecutableInPath(args[0], path)
+}
+
+// Checks, if desktop is defined by user configuration or by its profile.
+func (d *desktop) isUserConfig() bool {
+	return d.isUser || d.isProfile()
+}
+
+// Checks, if desktop is defined by profile of user configuration.
+func (d *desktop) isProfile() bool {
+	return strings.HasPrefix(d.id, constProfileIdPrefix)
+}
+
 // Gets correct desktop name, if is available.
 func (d *desktop) getDesktopName() string {
 	if d.desktopNames != "" {
@@ -87,6 +175,14 @@ func (d *desktop) getDesktopName() string {
 	return d.name
 }
 
+// Sets environment variable, that is defined only for this desktop
+func (d *desktop) setEnvVar(key, value string) {
+	if d.envVars == nil {
+		d.envVars = make(map[string]string)
+	}
+	d.envVars[key] = value
+}
+
 // Sets desktop names in expected format
 func (d *desktop) setDesktopNames(desktopNames string) {
 	val := sanitizeValue(desktopNames, "")
@@ -105,50 +201,123 @@ func (d *desktop) setDesktopNames(desktopNames string) {
 
 // lastSession defines structure for last used session on user login.
 type lastSession struct {
+	id   string
 	exec string
 	env  enEnvironment
 }
 
 // Allows to select desktop, which could be selected.
 func selectDesktop(auth authHandle, conf *config, d *desktop) (*desktop, *desktop) {
-	allowAutoselectDesktop := d == nil || d.selection == SelectionFalse
 	usr := auth.usr()
 
-	desktops := listAllDesktops(usr, conf.XorgSessionsPath, conf.WaylandSessionsPath)
+	desktops := listAvailableDesktops(conf, usr)
 	if len(desktops) == 0 {
 		handleStrErr("Not found any installed desktop.")
 	}
 
-	lastDesktop := getLastDesktop(usr, desktops)
+	lastDesktop := getLastDesktop(conf, usr, desktops)
+	notFound := false
+
+	if session := auth.getSession(); session != "" {
+		if d, _ := findSelectedDesktop(session, desktops); d != nil {
+			return d, desktops[lastDesktop]
+		}
+		fmt.Printf("\n%sSession '%s' not found\n", conf.GetIndentString(), session)
+		notFound = true
+	}
+
+	if selected := findPreselectedDesktop(conf, d, desktops); selected != nil {
+		return selected, desktops[lastDesktop]
+	}
+	if conf.DefaultSession != "" && (d == nil || d.selection == SelectionFalse) {
+		fmt.Printf("\n%sDefault session '%s' not found\n", conf.GetIndentString(), conf.DefaultSession)
+		notFound = true
+	}
+
+	// Otherwise go through selection process, failsafe session is available, if expected session was not found
+	if notFound {
+		desktops = append(desktops, getFailsafeDesktop(conf, getUserSessionPath(conf, usr)))
+	}
+	return chooseDesktop(auth, conf, desktops, lastDesktop), desktops[lastDesktop]
+}
+
+// Finds desktop, that is selected without user interaction by AUTOLOGIN_SESSION, DEFAULT_SESSION or AUTO_SELECTION.
+func findPreselectedDesktop(conf *config, d *desktop, desktops []*desktop) *desktop {
+	allowAutoselectDesktop := d == nil || d.selection == SelectionFalse
 
 	if conf.Autologin && conf.AutologinSession != "" {
 		if d := findAutoselectDesktop(conf.AutologinSession, conf.AutologinSessionEnv, desktops); d != nil {
-			return d, desktops[lastDesktop]
+			return d
 		}
+		logPrintf("Autologin session '%s' not found", conf.AutologinSession)
 	}
 
 	if conf.DefaultSession != "" && allowAutoselectDesktop {
 		if d := findAutoselectDesktop(conf.DefaultSession, conf.DefaultSessionEnv, desktops); d != nil {
-			return d, desktops[lastDesktop]
+			return d
 		}
+		logPrintf("Default session '%s' not found", conf.DefaultSession)
 	}
 
 	// If there is just one desktop and AutoSelection is set or selection is set to Auto, select first desktop
 	if len(desktops) == 1 && (conf.AutoSelection || (d != nil && d.selection == SelectionAuto)) {
-		return desktops[0], desktops[lastDesktop]
+		return desktops[0]
+	}
+	return nil
+}
+
+// Selects desktop from list, if the chosen session could not be started. Failsafe session is always available.
+func selectFallbackDesktop(auth authHandle, conf *config) *desktop {
+	usr := auth.usr()
+	desktops := append(listAvailableDesktops(conf, usr), getFailsafeDesktop(conf, getUserSessionPath(conf, usr)))
+	return chooseDesktop(auth, conf, desktops, len(desktops)-1)
+}
+
+// Lists all desktops, that could be selected by user.
+func listAvailableDesktops(conf *config, usr *sysuser) []*desktop {
+	desktops := listAllDesktops(usr, conf.XorgSessionsPath, conf.WaylandSessionsPath)
+	desktops = append(desktops, loadUserProfiles(usr.homedir)...)
+	if conf.ConsoleSession {
+		desktops = append(desktops, getConsoleDesktop(conf))
 	}
+	if conf.SessionSort == SessionSortUsage {
+		loadSessionStats(usr, desktops)
+	}
+	return arrangeDesktops(conf, checkAvailableDesktops(conf, usr, desktops))
+}
 
-	// Otherwise go through selection process
+// Lets user choose desktop from list, desktop on preselected index is used on empty input.
+func chooseDesktop(auth authHandle, conf *config, desktops []*desktop, preselected int) *desktop {
 	indent := conf.GetIndentString()
+	interactive := isInteractiveSelection(conf)
 	for {
+		if interactive {
+			selected, err := selectDesktopInteractively(conf, desktops, preselected)
+			if err != nil {
+				logPrint(err)
+				interactive = false
+				continue
+			}
+			if selected >= 0 {
+				return desktops[selected]
+			}
+
+			fmt.Printf("\n%s:", indent)
+			command, _ := stdinReader.ReadString('\n')
+			if err = processCommand(formatCommand(":"+strings.TrimSpace(command)), conf, auth, true); !errors.Is(err, errPrintCommandHelp) {
+				fmt.Printf("\n%s\n", err)
+			}
+			continue
+		}
+
 		fmt.Printf("\n")
 		printDesktops(conf, desktops)
-		fmt.Printf("\n\n%sSelect [%d]: ", indent, lastDesktop)
+		fmt.Printf("\n\n%sSelect [%d]: ", indent, preselected)
 
-		selection, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		selection, _ := stdinReader.ReadString('\n')
 		selection = strings.TrimSpace(selection)
 		if selection == "" {
-			selection = strconv.Itoa(lastDesktop)
+			selection = strconv.Itoa(preselected)
 		}
 
 		id, err := strconv.ParseUint(selection, 10, 32)
@@ -157,24 +326,58 @@ func selectDesktop(auth authHandle, conf *config, d *desktop) (*desktop, *deskto
 				if err = processCommand(formatCommand(selection), conf, auth, true); !errors.Is(err, errPrintCommandHelp) {
 					fmt.Printf("\n%s\n", err)
 				}
+				continue
+			}
+			d, candidates := findSelectedDesktop(selection, desktops)
+			if d != nil {
+				return d
+			}
+			if len(candidates) > 1 {
+				var names []string
+				for _, c := range candidates {
+					names = append(names, c.name)
+				}
+				fmt.Printf("\n%sambiguous: did you mean %s?\n", indent, strings.Join(names, ", "))
 			}
 			continue
 		}
 		if int(id) < len(desktops) {
-			return desktops[id], desktops[lastDesktop]
+			return desktops[id]
 		}
 	}
 }
 
+// Checks, if command of desktop could be started. If desktop requires selection, its selected child is checked.
+// User configuration started through login shell is checked by its command, not by the shell.
+func checkDesktopExec(conf *config, usr *sysuser, d *desktop) error {
+	if d.selection != SelectionFalse && d.child != nil {
+		d = d.child
+	}
+	if d.isAvailable(getUserSessionPath(conf, usr)) {
+		return nil
+	}
+
+	name := d.name
+	if name == "" {
+		name = d.exec
+	}
+	return errors.New("Session '" + name + "' could not be started, its command was not found")
+}
+
 // Prints list of desktops on screen
 func printDesktops(conf *config, desktops []*desktop) {
+	fprintDesktops(os.Stdout, conf, desktops, -1)
+}
+
+// Prints list of desktops into writer, desktop on selected index is highlighted.
+func fprintDesktops(w io.Writer, conf *config, desktops []*desktop, selected int) {
 	dSeparator := ", "
 	eSeparator := " "
 	if conf.VerticalSelection {
 		indent := conf.GetIndentString()
 		dSeparator = "\n" + indent
 		eSeparator = "\n" + indent
-		fmt.Print(indent)
+		fmt.Fprint(w, indent)
 	}
 
 	lastEnv := Undefined
@@ -182,27 +385,144 @@ func printDesktops(conf *config, desktops []*desktop) {
 		printSeparator := true
 		if conf.IdentifyEnvs && v.envOrigin != lastEnv {
 			if i > 0 {
-				fmt.Print(eSeparator)
-				fmt.Print(eSeparator)
+				fmt.Fprint(w, eSeparator)
+				fmt.Fprint(w, eSeparator)
 			}
 			lastEnv = v.envOrigin
-			fmt.Printf("|%s|%s", lastEnv.string(), eSeparator)
+			fmt.Fprintf(w, "|%s|%s", lastEnv.string(), eSeparator)
 			printSeparator = false
 		}
 
 		if printSeparator && i > 0 {
-			fmt.Print(dSeparator)
+			fmt.Fprint(w, dSeparator)
 		}
 
 		extraIndent := ""
 		if conf.VerticalSelection && conf.IndentSelection > 0 && i < 10 && len(desktops) > 10 {
 			extraIndent = " "
 		}
-		fmt.Printf("%s[%d] %s", extraIndent, i, v.name)
+		fmt.Fprint(w, extraIndent)
+		if i == selected {
+			fmt.Fprint(w, strReverseOn)
+		}
+		fmt.Fprintf(w, "[%d] %s", i, v.name)
+		if v.unavailable {
+			fmt.Fprint(w, " (unavailable)")
+		}
+		if i == selected {
+			fmt.Fprint(w, strReverseOff)
+		}
+	}
+}
+
+// Checks availability of desktops according to configuration and hides or marks unavailable ones.
+func checkAvailableDesktops(conf *config, usr *sysuser, desktops []*desktop) []*desktop {
+	if conf.UnavailableSessions == UnavailableShow {
+		return desktops
+	}
+
+	path := getUserSessionPath(conf, usr)
+	var result []*desktop
+	for _, d := range desktops {
+		d.unavailable = !d.isAvailable(path)
+		if d.unavailable && conf.UnavailableSessions == UnavailableHide {
+			logPrintf("Session '%s' is not available, %s is hidden", d.name, d.path)
+			continue
+		}
+		result = append(result, d)
+	}
+	return result
+}
+
+// Removes duplicated and hidden desktops and sorts them, desktops defined in SESSION_ORDER are pinned to the top.
+func arrangeDesktops(conf *config, desktops []*desktop) []*desktop {
+	var result []*desktop
+	ids := make(map[string]int)
+	for _, d := range desktops {
+		if matchDesktop(d, conf.HiddenSessions) >= 0 {
+			continue
+		}
+		if i, exists := ids[d.id]; exists && d.id != "" {
+			if getDesktopPriority(conf, d) > getDesktopPriority(conf, result[i]) {
+				result[i] = d
+			}
+			continue
+		}
+		ids[d.id] = len(result)
+		result = append(result, d)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		pinA, pinB := matchDesktop(a, conf.SessionOrder), matchDesktop(b, conf.SessionOrder)
+		if pinA != pinB {
+			return pinB < 0 || (pinA >= 0 && pinA < pinB)
+		}
+		switch conf.SessionSort {
+		case SessionSortUsage:
+			if less, differs := compareSessionUsage(a, b); differs {
+				return less
+			}
+		case SessionSortPath:
+			if a.path != b.path {
+				return a.path < b.path
+			}
+		}
+		if a.envOrigin != b.envOrigin {
+			return a.envOrigin < b.envOrigin
+		}
+		if nameA, nameB := strings.ToLower(a.name), strings.ToLower(b.name); nameA != nameB {
+			return nameA < nameB
+		}
+		return a.id < b.id
+	})
+	return result
+}
+
+// Gets index of first value matching desktop name or ID, -1 if desktop does not match any value.
+func matchDesktop(d *desktop, values []string) int {
+	for i, value := range values {
+		if strings.EqualFold(value, d.name) || (d.id != "" && strings.EqualFold(value, d.id)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Gets priority of desktop used for de-duplication, custom sessions and sessions of default environment are preferred.
+func getDesktopPriority(conf *config, d *desktop) int {
+	switch {
+	case d.envOrigin == UserCustom:
+		return 3
+	case d.envOrigin == Custom:
+		return 2
+	case d.env == conf.DefaultEnv:
+		return 1
+	}
+	return 0
+}
+
+// Finds desktop by its name, exec or unique prefix of name or ID. If prefix is ambiguous, all matching desktops are returned.
+func findSelectedDesktop(selection string, desktops []*desktop) (*desktop, []*desktop) {
+	if d := findAutoselectDesktop(selection, Undefined, desktops); d != nil {
+		return d, nil
+	}
+
+	var candidates []*desktop
+	prefix := strings.ToLower(selection)
+	for _, d := range desktops {
+		if strings.HasPrefix(strings.ToLower(d.name), prefix) || (d.id != "" && strings.HasPrefix(strings.ToLower(d.id), prefix)) {
+			candidates = append(candidates, d)
+		}
 	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	return nil, candidates
 }
 
 // Finds defined autologinSession in array of desktops by its exec or its name and environment, if defined.
+// If autologinSession contains additional arguments, copy of desktop with these arguments is returned.
 func findAutoselectDesktop(autologinSession string, env enEnvironment, desktops []*desktop) *desktop {
 	// search by session name first
 	for _, d := range desktops {
@@ -227,7 +547,12 @@ func findAutoselectDesktop(autologinSession string, env enEnvironment, desktops
 		desktopExec, _ := getDesktopBaseExec(d.exec)
 		if exec == desktopExec {
 			if args != "" {
-				d.exec = d.exec + " " + args
+				withArgs := *d
+				withArgs.exec = d.exec + " " + args
+				if d.execArgs != nil {
+					withArgs.execArgs = append(append([]string{}, d.execArgs...), parseExec(args)...)
+				}
+				return &withArgs
 			}
 			return d
 		}
@@ -249,39 +574,126 @@ func getDesktopBaseExec(exec string) (string, string) {
 
 // List all installed desktops and return their exec commands.
 func listAllDesktops(usr *sysuser, pathXorgDesktops, pathWaylandDesktops string) []*desktop {
+	return listAllDesktopsWithHidden(usr, pathXorgDesktops, pathWaylandDesktops, false)
+}
+
+// List all desktops from all defined paths, if includeHidden is true, desktops with NoDisplay or Hidden are also returned.
+func listAllDesktopsWithHidden(usr *sysuser, pathXorgDesktops, pathWaylandDesktops string, includeHidden bool) []*desktop {
 	var result []*desktop
 
 	// load Xorg desktops
-	result = append(result, listDesktops(Xorg, pathXorgDesktops, usr.homedir+pathLocalXSessions)...)
+	result = append(result, listDesktopsWithHidden(Xorg, includeHidden, getSessionDirs(usr, pathXorgDesktops, pathXSessions)...)...)
 
 	// load Wayland desktops
-	result = append(result, listDesktops(Wayland, pathWaylandDesktops, usr.homedir+pathLocalWaylandSessions)...)
+	result = append(result, listDesktopsWithHidden(Wayland, includeHidden, getSessionDirs(usr, pathWaylandDesktops, pathWaylandSessions)...)...)
 
 	// load custom desktops
-	result = append(result, listDesktops(Custom, pathCustomSessions)...)
+	result = append(result, listDesktopsWithHidden(Custom, includeHidden, pathCustomSessions)...)
 
 	// load custom user desktops
-	result = append(result, listDesktops(UserCustom, usr.homedir+pathUserCustomSession)...)
+	result = append(result, listDesktopsWithHidden(UserCustom, includeHidden, usr.homedir+pathUserCustomSession)...)
 
 	return result
 }
 
-// List desktops, that could be found on defined paths.
+// Gets directories with sessions ordered by priority: XDG_DATA_HOME, configured path and XDG_DATA_DIRS. If configured path
+// is one of XDG_DATA_DIRS entries, it keeps its position.
+func getSessionDirs(usr *sysuser, configPath, sessionsDir string) []string {
+	dataHome := usr.getenv(envXdgDataHome)
+	if dataHome == "" {
+		dataHome = usr.homedir + pathLocalShare
+	}
+
+	dataDirs := usr.getenv(envXdgDataDirs)
+	if dataDirs == "" {
+		dataDirs = os.Getenv(envXdgDataDirs)
+	}
+	if dataDirs == "" {
+		dataDirs = defaultXdgDataDirs
+	}
+
+	var xdgDirs []string
+	for _, dir := range filepath.SplitList(dataDirs) {
+		if dir == "" {
+			continue
+		}
+		dir = filepath.Join(dir, sessionsDir)
+		if configPath != "" && filepath.Clean(configPath) == dir {
+			dir = configPath
+			configPath = ""
+		}
+		xdgDirs = append(xdgDirs, dir)
+	}
+
+	dirs := append([]string{filepath.Join(dataHome, sessionsDir), configPath}, xdgDirs...)
+
+	var result []string
+	known := make(map[string]bool)
+	for _, dir := range dirs {
+		if dir == "" || known[filepath.Clean(dir)] {
+			continue
+		}
+		known[filepath.Clean(dir)] = true
+		result = append(result, dir)
+	}
+	return result
+}
+
+// Gets built-in console desktop, that starts CONSOLE_COMMAND or user's login shell.
+func getConsoleDesktop(conf *config) *desktop {
+	return &desktop{id: constEnvConsole, name: constEnvSConsole, exec: conf.ConsoleCommand, env: Console, envOrigin: Console}
+}
+
+// Gets failsafe desktop, that starts FAILSAFE_COMMAND under bare Xorg. If command is not available, login shell is started on TTY.
+func getFailsafeDesktop(conf *config, path string) *desktop {
+	d := &desktop{id: constFailsafeId, name: constFailsafeName, exec: conf.FailsafeCommand, env: Xorg, envOrigin: Xorg, failsafe: true}
+	if conf.FailsafeCommand == "" || !d.isAvailable(path) {
+		d.exec = ""
+		d.env = Console
+		d.envOrigin = Console
+	}
+	return d
+}
+
+// List desktops, that could be found on defined paths. Desktop with same ID found on later path is shadowed.
 func listDesktops(env enEnvironment, paths ...string) []*desktop {
+	return listDesktopsWithHidden(env, false, paths...)
+}
+
+// List desktops, that could be found on defined paths. If includeHidden is true, desktops with NoDisplay or Hidden are also returned.
+func listDesktopsWithHidden(env enEnvironment, includeHidden bool, paths ...string) []*desktop {
 	var result []*desktop
+	ids := make(map[string]bool)
 
 	for _, path := range paths {
-		if strings.HasSuffix(path, "/") {
+		if !strings.HasSuffix(path, "/") {
 			path += "/"
 		}
 
 		if fileExists(path) {
 			err := filepath.Walk(path, func(filePath string, fileInfo os.FileInfo, err error) error {
-				if !fileInfo.IsDir() && strings.HasSuffix(filePath, ".desktop") {
+				if err == nil && !fileInfo.IsDir() && strings.HasSuffix(filePath, ".desktop") {
+					id := getDesktopId(path, filePath)
+					if ids[id] {
+						return nil
+					}
+					ids[id] = true
+
 					d := getDesktop(filePath, env)
-					if !d.noDisplay && !d.hidden {
+					d.id = id
+					if includeHidden || (!d.noDisplay && !d.hidden) {
 						result = append(result, d)
 					}
+				} else if err == nil && isCustomSessionScript(env, filePath, fileInfo) {
+					id := getDesktopId(path, filePath)
+					if ids[id] {
+						return nil
+					}
+					ids[id] = true
+
+					d := getScriptDesktop(filePath, env)
+					d.id = id
+					result = append(result, d)
 				}
 				return nil
 			})
@@ -292,79 +704,250 @@ func listDesktops(env enEnvironment, paths ...string) []*desktop {
 	return result
 }
 
-// Inits desktop object from .desktop file on defined path.
-func getDesktop(path string, env enEnvironment) *desktop {
-	d := desktop{env: env, envOrigin: env, isUser: false, path: path}
-	if env == Custom || env == UserCustom {
-		d.env = defaultEnvValue
+// Checks, if file is executable script, that could be used as custom session.
+func isCustomSessionScript(env enEnvironment, filePath string, fileInfo os.FileInfo) bool {
+	name := fileInfo.Name()
+	return (env == Custom || env == UserCustom) && !fileInfo.IsDir() && !strings.HasPrefix(name, ".") && !strings.HasSuffix(name, "~") && fileIsExecutable(filePath)
+}
+
+// Inits desktop object from executable script, name and environment could be defined in header comments.
+func getScriptDesktop(path string, env enEnvironment) *desktop {
+	d := &desktop{env: defaultEnvValue, envOrigin: env, path: path, exec: path, execArgs: []string{path}}
+	d.name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	file, err := os.Open(path)
+	if err != nil {
+		logPrint(err)
+		return d
 	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#!") {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
 
-	readProperties(path, func(key string, value string) {
-		switch key {
+		key, value, found := strings.Cut(strings.TrimSpace(line[1:]), ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToUpper(strings.TrimSpace(key)) {
 		case desktopName:
-			d.name = value
-		case desktopExec:
-			d.exec = value
+			if value != "" {
+				d.name = value
+			}
 		case desktopEnvironment, desktopEnv:
 			d.env = parseEnv(value, defaultEnv())
 		case desktopNames:
 			d.setDesktopNames(value)
-		case desktopNoDisplay:
-			d.noDisplay = parseBool(value, "false")
-		case desktopHidden:
-			d.hidden = parseBool(value, "false")
 		}
-	})
+	}
+	return d
+}
+
+// Gets desktop file ID as relative path to its base directory with slashes replaced by dashes.
+func getDesktopId(basePath, filePath string) string {
+	rel, err := filepath.Rel(basePath, filePath)
+	if err != nil {
+		rel = filepath.Base(filePath)
+	}
+	return strings.TrimSuffix(strings.ReplaceAll(rel, "/", "-"), ".desktop")
+}
+
+// Inits desktop object from .desktop file on defined path.
+func getDesktop(path string, env enEnvironment) *desktop {
+	d := desktop{env: env, envOrigin: env, isUser: false, path: path}
+	if env == Custom || env == UserCustom {
+		d.env = defaultEnvValue
+	}
+
+	e, err := readDesktopEntry(path)
+	if err != nil {
+		logPrint(err)
+		return &d
+	}
+
+	d.name = e.getLocaleString(desktopName, desktopLocale)
+	d.exec = e.getString(desktopExec)
+	if value, exists := e.getRaw(desktopEnvironment); exists {
+		d.env = parseEnv(unescapeDesktopValue(value), defaultEnv())
+	} else if value, exists := e.getRaw(desktopEnv); exists {
+		d.env = parseEnv(unescapeDesktopValue(value), defaultEnv())
+	}
+	d.setDesktopNames(strings.Join(e.getStrings(desktopNames), ":"))
+	d.noDisplay = e.getBool(desktopNoDisplay)
+	d.hidden = e.getBool(desktopHidden)
+	d.tryExec = e.getString(desktopTryExec)
+
+	if d.exec != "" {
+		if d.execArgs, err = parseDesktopExec(d.exec, d.name, e.getString(desktopIcon), path); err != nil {
+			logPrintf("%s: %s", path, err)
+		}
+	}
+
+	for _, v := range e.values {
+		if len(v.key) > len(desktopEnvPrefix) && strings.EqualFold(v.key[:len(desktopEnvPrefix)], desktopEnvPrefix) {
+			d.setEnvVar(v.key[len(desktopEnvPrefix):], unescapeDesktopValue(v.value))
+		}
+	}
+	d.preExec = parseDesktopArgs(e, desktopPreExec)
+	d.postExec = parseDesktopArgs(e, desktopPostExec)
+	d.xorgArgs = parseDesktopArgs(e, desktopXorgArgs)
+	d.xServer = e.getString(desktopXServer)
 	return &d
 }
 
+// Parses command or arguments defined by key in desktop entry.
+func parseDesktopArgs(e *desktopEntry, key string) []string {
+	value := e.getString(key)
+	if value == "" {
+		return nil
+	}
+	args, err := parseDesktopExec(value, "", "", e.path)
+	if err != nil {
+		logPrintf("%s: %s %s", e.path, key, err)
+	}
+	return args
+}
+
 // Parses user-specified configuration from file and returns it as desktop structure.
 func loadUserDesktop(homeDir string) (d *desktop, lang string) {
-	homeDirConf := homeDir + "/.emptty"
-	confDirConf := homeDir + "/.config/emptty"
+	confFile := getUserConfigPath(homeDir)
+	if confFile == "" {
+		return nil, lang
+	}
 
-	for _, confFile := range []string{confDirConf, homeDirConf} {
-		if !fileExists(confFile) {
-			continue
+	d = &desktop{isUser: true, path: confFile, env: defaultEnvValue, selection: SelectionFalse}
+	hasMainSection, hasProfiles := false, false
+
+	err := readRawPropertiesWithSections(confFile, func(section, key, value string) {
+		key = normalizeUserPropertyKey(key)
+		if section != "" {
+			hasProfiles = true
+			return
+		}
+		hasMainSection = true
+		if key == desktopLang {
+			lang = value
+		} else {
+			d.setUserProperty(key, value)
 		}
-		d := &desktop{isUser: true, path: confFile, env: defaultEnvValue, selection: SelectionFalse}
+	}, true)
+	handleErr(err)
 
-		err := readPropertiesWithSupport(confFile, func(key string, value string) {
-			switch key {
-			case desktopName:
-				d.name = value
-			case desktopExec, confCommand:
-				d.exec = sanitizeValue(value, "")
-			case desktopEnvironment, desktopEnv:
-				d.env = parseEnv(value, defaultEnv())
-			case desktopLang:
-				lang = value
-			case confSelection:
-				d.selection = parseSelection(value, "false")
-			case desktopLoginShell:
-				d.loginShell = sanitizeValue(value, "")
-			case desktopNames:
-				d.setDesktopNames(value)
-			}
-		}, true)
-		handleErr(err)
+	if !hasMainSection && hasProfiles {
+		return nil, lang
+	}
+
+	if d.selection != SelectionFalse {
+		d.exec = ""
+		d.name = ""
+		d.desktopNames = ""
+	}
+
+	return d, lang
+}
+
+// Loads profiles defined as [name] sections in user configuration, each profile is handled as user custom session.
+func loadUserProfiles(homeDir string) []*desktop {
+	confFile := getUserConfigPath(homeDir)
+	if confFile == "" {
+		return nil
+	}
+
+	var result []*desktop
+	profiles := make(map[string]*desktop)
+	err := readRawPropertiesWithSections(confFile, func(section, key, value string) {
+		key = normalizeUserPropertyKey(key)
+		if section == "" {
+			return
+		}
+		p, exists := profiles[section]
+		if !exists {
+			p = &desktop{id: constProfileIdPrefix + section, name: section, path: confFile, env: defaultEnvValue, envOrigin: UserCustom}
+			profiles[section] = p
+			result = append(result, p)
+		}
+		if key == desktopLang {
+			p.lang = value
+		} else {
+			p.setUserProperty(key, value)
+		}
+	}, true)
+	if err != nil {
+		logPrint(err)
+		return nil
+	}
+
+	var profilesWithExec []*desktop
+	for _, p := range result {
+		if p.exec == "" {
+			logPrintf("Profile '%s' in %s does not define Exec, it is skipped", p.name, confFile)
+			continue
+		}
+		profilesWithExec = append(profilesWithExec, p)
+	}
+	return profilesWithExec
+}
 
-		if d.selection != SelectionFalse {
-			d.exec = ""
-			d.name = ""
-			d.desktopNames = ""
+// Gets path to user configuration, ${HOME}/.config/emptty has higher priority than ${HOME}/.emptty.
+func getUserConfigPath(homeDir string) string {
+	for _, confFile := range []string{homeDir + "/.config/emptty", homeDir + "/.emptty"} {
+		if fileExists(confFile) {
+			return confFile
 		}
+	}
+	return ""
+}
 
-		return d, lang
+// Normalizes key of user configuration to upper case, only name of variable defined by ENV_ prefix keeps its original case.
+func normalizeUserPropertyKey(key string) string {
+	upperKey := strings.ToUpper(key)
+	if strings.HasPrefix(upperKey, confEnvPrefix) {
+		return confEnvPrefix + key[len(confEnvPrefix):]
 	}
+	return upperKey
+}
 
-	return nil, lang
+// Sets property defined in user configuration.
+func (d *desktop) setUserProperty(key, value string) {
+	switch key {
+	case desktopName:
+		d.name = value
+	case desktopExec, confCommand:
+		d.exec = sanitizeValue(value, "")
+	case desktopEnvironment, desktopEnv:
+		d.env = parseEnv(value, defaultEnv())
+	case confSelection:
+		d.selection = parseSelection(value, "false")
+	case desktopLoginShell:
+		d.loginShell = sanitizeValue(value, "")
+	case desktopNames:
+		d.setDesktopNames(value)
+	default:
+		if strings.HasPrefix(key, confEnvPrefix) && len(key) > len(confEnvPrefix) {
+			d.setEnvVar(key[len(confEnvPrefix):], value)
+		}
+	}
 }
 
 // Gets index of last used desktop.
-func getLastDesktop(usr *sysuser, desktops []*desktop) int {
-	l := getUserLastSession(usr)
+func getLastDesktop(conf *config, usr *sysuser, desktops []*desktop) int {
+	l := getUserLastSession(conf, usr)
 	if l != nil {
+		if l.id != "" {
+			for i, d := range desktops {
+				if d.id == l.id && d.env == l.env {
+					return i
+				}
+			}
+		}
 		for i, d := range desktops {
 			if d.exec == l.exec && d.env == l.env {
 				return i
@@ -374,40 +957,108 @@ func getLastDesktop(usr *sysuser, desktops []*desktop) int {
 	return 0
 }
 
-// Gets user last session stored in his own home directory.
-func getUserLastSession(usr *sysuser) *lastSession {
-	path := usr.homedir + pathLastSession
-	if fileExists(path) {
-		if content, err := os.ReadFile(path); err == nil {
-			arrContent := strings.Split(strings.TrimSpace(string(content)), ";")
-			l := lastSession{}
-			l.exec = strings.TrimSpace(arrContent[0])
-			if len(arrContent) > 1 {
-				l.env = parseEnv(arrContent[1], defaultEnv())
-				return &l
-			}
+// Gets path to file with last session, it is TTY specific if LAST_SESSION_PER_TTY is enabled.
+func getLastSessionPath(conf *config, usr *sysuser) string {
+	if conf.LastSessionPerTty {
+		return usr.homedir + pathLastSession + "-tty" + conf.strTTY()
+	}
+	return usr.homedir + pathLastSession
+}
+
+// Gets user last session stored in his own home directory. TTY specific session is preferred, if enabled.
+func getUserLastSession(conf *config, usr *sysuser) *lastSession {
+	if l := readLastSession(getLastSessionPath(conf, usr)); l != nil {
+		return l
+	}
+	return readLastSession(usr.homedir + pathLastSession)
+}
+
+// Reads last session from file, older format "exec;env" is supported as well.
+func readLastSession(path string) *lastSession {
+	if !fileExists(path) {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	text := strings.TrimSpace(string(content))
+	l := lastSession{}
+	if !strings.HasPrefix(text, lastSessionId+"=") && !strings.HasPrefix(text, desktopEnv+"=") {
+		arrContent := strings.Split(text, ";")
+		l.exec = strings.TrimSpace(arrContent[0])
+		if len(arrContent) > 1 {
+			l.env = parseEnv(arrContent[1], defaultEnv())
+			return &l
 		}
+		return nil
 	}
-	return nil
+
+	for _, line := range strings.Split(text, "\n") {
+		key, value, _ := strings.Cut(line, "=")
+		switch strings.TrimSpace(key) {
+		case lastSessionId:
+			l.id = strings.TrimSpace(value)
+		case desktopEnv:
+			l.env = parseEnv(value, defaultEnv())
+		case desktopExec:
+			l.exec = strings.TrimSpace(value)
+		}
+	}
+	return &l
 }
 
-// Sets Last session for declared sysuser and saves it into user's home directory.
-func setUserLastSession(usr *sysuser, d *desktop) {
+// Sets Last session for declared sysuser and saves it into user's home directory. Exec is saved only for desktops without ID.
+func setUserLastSession(conf *config, usr *sysuser, d *desktop) {
 	doAsUser(usr, func() {
-		path := usr.homedir + pathLastSession
-		data := fmt.Sprintf("%s;%s\n", d.exec, d.env.stringify())
-		if err := mkDirsForFile(path, 0744); err != nil {
-			logPrint(err)
+		data := fmt.Sprintf("%s=%s\n%s=%s\n", lastSessionId, d.id, desktopEnv, d.env.stringify())
+		if d.id == "" {
+			data += fmt.Sprintf("%s=%s\n", desktopExec, d.exec)
+		}
+		paths := []string{usr.homedir + pathLastSession}
+		if conf.LastSessionPerTty {
+			paths = append(paths, getLastSessionPath(conf, usr))
 		}
-		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
-			logPrint(err)
+		for _, path := range paths {
+			if err := mkDirsForFile(path, 0744); err != nil {
+				logPrint(err)
+			}
+			if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+				logPrint(err)
+			}
 		}
 	})
 }
 
-// Checks, if user last session file already exists.
-func isLastDesktopForSave(usr *sysuser, lastDesktop, currentDesktop *desktop) bool {
-	return !fileExists(usr.homedir+pathLastSession) || lastDesktop.exec != currentDesktop.exec || lastDesktop.env != currentDesktop.env
+// Checks, if user last session needs to be saved. Session stored in older format is always saved again.
+func isLastDesktopForSave(conf *config, usr *sysuser, lastDesktop, currentDesktop *desktop) bool {
+	if !fileExists(getLastSessionPath(conf, usr)) {
+		return true
+	}
+	if l := getUserLastSession(conf, usr); l == nil || (l.id == "" && currentDesktop.id != "") {
+		return true
+	}
+	if lastDesktop.id != "" || currentDesktop.id != "" {
+		return lastDesktop.id != currentDesktop.id || lastDesktop.env != currentDesktop.env
+	}
+	return lastDesktop.exec != currentDesktop.exec || lastDesktop.env != currentDesktop.env
+}
+
+// Parses unavailable sessions option.
+func parseUnavailableSessions(value, defaultValue string) enUnavailable {
+	switch strings.ToLower(sanitizeValue(value, defaultValue)) {
+	case constUnavailableMark:
+		return UnavailableMark
+	case constUnavailableShow:
+		return UnavailableShow
+	}
+	return UnavailableHide
+}
+
+// Stringifies unavailable sessions option.
+func (u enUnavailable) stringify() string {
+	return []string{constUnavailableHide, constUnavailableMark, constUnavailableShow}[int(u)]
 }
 
 // Parse input selection
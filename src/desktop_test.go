@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"os/user"
+	"strings"
 	"testing"
 )
 
@@ -203,10 +204,10 @@ func TestGetDesktop(t *testing.T) {
 func TestGetUserLastSession(t *testing.T) {
 	usr := &sysuser{}
 	usr.homedir = getTestingPath("userHome2")
-	getUserLastSession(usr)
+	getUserLastSession(&config{}, usr)
 
 	usr.homedir = getTestingPath("userHome")
-	s := getUserLastSession(usr)
+	s := getUserLastSession(&config{}, usr)
 
 	if s.env != Wayland {
 		t.Error("TestGetUserLastSession: wrong env value")
@@ -223,7 +224,7 @@ func TestGetLastDesktop(t *testing.T) {
 
 	desktops := []*desktop{{exec: "/usr/bin/none", env: Xorg}, {exec: "/usr/bin/none", env: Wayland}, {exec: "/usr/bin/none2", env: Wayland}}
 
-	if getLastDesktop(usr, desktops) != 1 {
+	if getLastDesktop(&config{}, usr, desktops) != 1 {
 		t.Error("TestGetLastDesktop: expected different index")
 	}
 }
@@ -256,18 +257,18 @@ func TestIsLastDesktopForSave(t *testing.T) {
 	usr := &sysuser{}
 	usr.homedir = "/dev/null"
 
-	if !isLastDesktopForSave(usr, lastDesktop, currentDesktop) {
+	if !isLastDesktopForSave(&config{}, usr, lastDesktop, currentDesktop) {
 		t.Error("TestIsLastDesktopForSave: file not exists and doesn't need to save")
 	}
 
 	usr.homedir = getTestingPath("userHome")
 
-	if isLastDesktopForSave(usr, lastDesktop, currentDesktop) {
+	if isLastDesktopForSave(&config{}, usr, lastDesktop, currentDesktop) {
 		t.Error("TestIsLastDesktopForSave: desktops should not need to save")
 	}
 
 	lastDesktop.env = Xorg
-	if !isLastDesktopForSave(usr, lastDesktop, currentDesktop) {
+	if !isLastDesktopForSave(&config{}, usr, lastDesktop, currentDesktop) {
 		t.Error("TestIsLastDesktopForSave: desktop should be saved, env is different")
 	}
 }
@@ -279,7 +280,7 @@ func TestSetUserLastSession(t *testing.T) {
 	usr := getSysuser(currentUser)
 	usr.homedir = "/tmp/emptty-test/"
 
-	setUserLastSession(usr, d)
+	setUserLastSession(&config{}, usr, d)
 
 	if !fileExists(usr.homedir + pathLastSession) {
 		t.Error("TestSetUserLastSession: last session is not being saved")
@@ -289,6 +290,7 @@ func TestSetUserLastSession(t *testing.T) {
 }
 
 func TestListAllDesktops(t *testing.T) {
+	t.Setenv(envXdgDataDirs, "/dev/null")
 	usr := &sysuser{}
 	usr.homedir = getTestingPath("userHome2")
 
@@ -307,6 +309,7 @@ func TestListAllDesktops(t *testing.T) {
 }
 
 func TestFindAutoselectDesktop(t *testing.T) {
+	t.Setenv(envXdgDataDirs, "/dev/null")
 	usr := &sysuser{}
 	usr.homedir = getTestingPath("userHome2")
 
@@ -333,6 +336,19 @@ func TestFindAutoselectDesktop(t *testing.T) {
 	}
 }
 
+func TestFindAutoselectDesktopWithArgs(t *testing.T) {
+	desktops := []*desktop{{id: "sway", name: "Sway", exec: "/usr/bin/sway", execArgs: []string{"/usr/bin/sway"}, env: Wayland}}
+
+	d := findAutoselectDesktop("sway --unsupported-gpu", Undefined, desktops)
+	if d == nil || d == desktops[0] || d.id != "sway" || d.exec != "/usr/bin/sway --unsupported-gpu" || len(d.execArgs) != 2 {
+		t.Error("TestFindAutoselectDesktopWithArgs: copy of desktop with arguments was expected")
+	}
+
+	if desktops[0].exec != "/usr/bin/sway" || len(desktops[0].execArgs) != 1 {
+		t.Error("TestFindAutoselectDesktopWithArgs: arguments should not be appended to listed desktop")
+	}
+}
+
 func TestGetStrExec(t *testing.T) {
 	d := &desktop{path: "/dev/null", exec: "/usr/bin/none"}
 
@@ -394,3 +410,328 @@ func TestGetDesktopName(t *testing.T) {
 		t.Errorf("TestGetDesktopName: desktop2 got unexpected desktop name '%s'", d.getDesktopName())
 	}
 }
+
+func TestCheckAvailableDesktops(t *testing.T) {
+	desktops := listDesktops(Wayland, getTestingPath("desktop-entries"))
+	if len(desktops) != 3 {
+		t.Error("TestCheckAvailableDesktops: unexpected count of desktops")
+		return
+	}
+
+	usr := &sysuser{uid: 1000}
+	conf := &config{Defs: &loginDefs{envPath: "/bin:/usr/bin"}, UnavailableSessions: UnavailableMark}
+
+	result := checkAvailableDesktops(conf, usr, desktops)
+	if len(result) != 3 {
+		t.Error("TestCheckAvailableDesktops: marked desktops should not be removed")
+	}
+	for _, d := range result {
+		if !d.unavailable {
+			t.Errorf("TestCheckAvailableDesktops: unexpected availability of '%s'", d.name)
+		}
+	}
+
+	output := readOutput(func() {
+		printDesktops(conf, result[:1])
+	})
+	if output != "[0] "+result[0].name+" (unavailable)" {
+		t.Error("TestCheckAvailableDesktops: unavailable desktop should be marked")
+	}
+
+	conf.UnavailableSessions = UnavailableHide
+	if len(checkAvailableDesktops(conf, usr, desktops)) != 0 {
+		t.Error("TestCheckAvailableDesktops: unavailable desktops should be hidden")
+	}
+
+	conf.UnavailableSessions = UnavailableShow
+	for _, d := range desktops {
+		d.unavailable = false
+	}
+	if len(checkAvailableDesktops(conf, usr, desktops)) != 3 || desktops[0].unavailable {
+		t.Error("TestCheckAvailableDesktops: all desktops should be shown without check")
+	}
+
+	d := &desktop{exec: "sh -c true"}
+	if !d.isAvailable("/bin:/usr/bin") {
+		t.Error("TestCheckAvailableDesktops: desktop with installed exec should be available")
+	}
+}
+
+func TestListAllDesktopsXdg(t *testing.T) {
+	t.Setenv(envXdgDataDirs, getTestingPath("xdg/share1")+":"+getTestingPath("xdg/share2"))
+	usr := &sysuser{homedir: "/dev/null", env: map[string]string{envXdgDataHome: getTestingPath("xdg/home")}}
+
+	desktops := listAllDesktops(usr, getTestingPath("desktops"), "/dev/null")
+
+	names := make(map[string]string)
+	for _, d := range desktops {
+		names[d.id] = d.name
+	}
+
+	if len(desktops) != 5 {
+		t.Errorf("TestListAllDesktopsXdg: unexpected count of desktops %d, 5 expected", len(desktops))
+	}
+
+	if names["shadow"] != "User Shadow" {
+		t.Error("TestListAllDesktopsXdg: user desktop should shadow system desktops with same ID")
+	}
+
+	if names["vendor-session"] != "Vendor Session" || names["nix"] != "Nix Session" {
+		t.Error("TestListAllDesktopsXdg: desktops from XDG_DATA_DIRS should be listed")
+	}
+
+	if names["desktop1"] != "Desktop1" || names["desktop2"] != "Desktop2" {
+		t.Error("TestListAllDesktopsXdg: desktops from configured path should be listed")
+	}
+
+	if _, exists := names["removed"]; exists {
+		t.Error("TestListAllDesktopsXdg: hidden desktop should hide desktops with same ID")
+	}
+}
+
+func TestGetSessionDirs(t *testing.T) {
+	t.Setenv(envXdgDataDirs, "")
+	usr := &sysuser{homedir: "/home/user", env: make(map[string]string)}
+
+	dirs := getSessionDirs(usr, "/usr/share/xsessions/", pathXSessions)
+	if strings.Join(dirs, ",") != "/home/user/.local/share/xsessions,/usr/local/share/xsessions,/usr/share/xsessions/" {
+		t.Errorf("TestGetSessionDirs: unexpected dirs %q", dirs)
+	}
+
+	dirs = getSessionDirs(usr, "/opt/xsessions", pathXSessions)
+	if strings.Join(dirs, ",") != "/home/user/.local/share/xsessions,/opt/xsessions,/usr/local/share/xsessions,/usr/share/xsessions" {
+		t.Errorf("TestGetSessionDirs: unexpected dirs %q", dirs)
+	}
+
+	usr.setenv(envXdgDataDirs, "/nix/share::/usr/share")
+	usr.setenv(envXdgDataHome, "/tmp/data")
+	dirs = getSessionDirs(usr, "", pathWaylandSessions)
+	if strings.Join(dirs, ",") != "/tmp/data/wayland-sessions,/nix/share/wayland-sessions,/usr/share/wayland-sessions" {
+		t.Errorf("TestGetSessionDirs: unexpected dirs %q", dirs)
+	}
+}
+
+func TestArrangeDesktops(t *testing.T) {
+	desktops := []*desktop{
+		{id: "sway", name: "Sway", env: Wayland, envOrigin: Wayland},
+		{id: "plasma", name: "Plasma", env: Xorg, envOrigin: Xorg},
+		{id: "i3", name: "i3", env: Xorg, envOrigin: Xorg},
+		{id: "plasma", name: "Plasma (Wayland)", env: Wayland, envOrigin: Wayland},
+		{id: "awesome", name: "Awesome", env: Xorg, envOrigin: Xorg},
+		{id: "sway", name: "Custom Sway", env: Wayland, envOrigin: Custom},
+		{id: "xfce", name: "Xfce Session", env: Xorg, envOrigin: Xorg},
+		{id: "", name: "Plain", env: Xorg, envOrigin: UserCustom},
+		{id: "", name: "Another", env: Xorg, envOrigin: UserCustom}}
+
+	getNames := func(desktops []*desktop) string {
+		var names []string
+		for _, d := range desktops {
+			names = append(names, d.name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	conf := &config{DefaultEnv: Xorg}
+	if result := getNames(arrangeDesktops(conf, desktops)); result != "Awesome,i3,Plasma,Xfce Session,Custom Sway,Another,Plain" {
+		t.Errorf("TestArrangeDesktops: unexpected order '%s'", result)
+	}
+
+	conf = &config{DefaultEnv: Wayland, SessionOrder: []string{"sway", "Xfce Session"}, HiddenSessions: []string{"AWESOME", "plain"}}
+	if result := getNames(arrangeDesktops(conf, desktops)); result != "Custom Sway,Xfce Session,i3,Plasma (Wayland),Another" {
+		t.Errorf("TestArrangeDesktops: unexpected order '%s'", result)
+	}
+}
+
+func TestLastSessionById(t *testing.T) {
+	usr := &sysuser{homedir: getTestingPath("userHome6")}
+	conf := &config{Tty: 3}
+
+	l := getUserLastSession(conf, usr)
+	if l == nil || l.id != "sway" || l.env != Wayland || l.exec != "sway --unsupported-gpu" {
+		t.Error("TestLastSessionById: last session was not correctly read")
+	}
+
+	desktops := []*desktop{{id: "i3", exec: "i3", env: Xorg}, {id: "sway", exec: "/usr/bin/sway", env: Wayland}}
+	if getLastDesktop(conf, usr, desktops) != 1 {
+		t.Error("TestLastSessionById: last desktop should be found by ID even with changed exec")
+	}
+
+	conf.LastSessionPerTty = true
+	if getLastDesktop(conf, usr, desktops) != 0 {
+		t.Error("TestLastSessionById: TTY specific last desktop should be preferred")
+	}
+
+	conf.Tty = 4
+	if getLastDesktop(conf, usr, desktops) != 1 {
+		t.Error("TestLastSessionById: global last desktop should be used as fallback")
+	}
+	if !isLastDesktopForSave(conf, usr, desktops[1], desktops[1]) {
+		t.Error("TestLastSessionById: missing TTY specific last session should be saved")
+	}
+
+	conf.Tty = 3
+	if isLastDesktopForSave(conf, usr, desktops[0], &desktop{id: "i3", exec: "i3 --changed", env: Xorg}) {
+		t.Error("TestLastSessionById: same ID should not need to save")
+	}
+
+	usr.homedir = getTestingPath("userHome")
+	if !isLastDesktopForSave(&config{}, usr, desktops[0], desktops[0]) {
+		t.Error("TestLastSessionById: last session in older format should be migrated")
+	}
+}
+
+func TestSetUserLastSessionPerTty(t *testing.T) {
+	currentUser, _ := user.Current()
+	usr := getSysuser(currentUser)
+	usr.homedir = t.TempDir()
+	conf := &config{Tty: 5, LastSessionPerTty: true}
+
+	setUserLastSession(conf, usr, &desktop{id: "sway", exec: "sway", env: Wayland})
+
+	for _, path := range []string{usr.homedir + pathLastSession, usr.homedir + pathLastSession + "-tty5"} {
+		l := readLastSession(path)
+		if l == nil || l.id != "sway" || l.exec != "" || l.env != Wayland {
+			t.Error("TestSetUserLastSessionPerTty: last session was not correctly saved to " + path)
+		}
+	}
+}
+
+func TestFindSelectedDesktop(t *testing.T) {
+	desktops := []*desktop{
+		{id: "sway", name: "Sway", exec: "/usr/bin/sway", env: Wayland},
+		{id: "swayfx", name: "SwayFX", exec: "/usr/bin/swayfx", env: Wayland},
+		{id: "plasma", name: "Plasma (X11)", exec: "startplasma-x11", env: Xorg},
+		{id: "plasmawayland", name: "Plasma", exec: "startplasma-wayland", env: Wayland}}
+
+	if d, _ := findSelectedDesktop("SWAY", desktops); d != desktops[0] {
+		t.Error("TestFindSelectedDesktop: desktop should be found by exact name")
+	}
+
+	if d, _ := findSelectedDesktop("startplasma-x11", desktops); d != desktops[2] {
+		t.Error("TestFindSelectedDesktop: desktop should be found by exec")
+	}
+
+	if d, _ := findSelectedDesktop("swayf", desktops); d != desktops[1] {
+		t.Error("TestFindSelectedDesktop: desktop should be found by unique prefix")
+	}
+
+	if d, _ := findSelectedDesktop("plasmaw", desktops); d != desktops[3] {
+		t.Error("TestFindSelectedDesktop: desktop should be found by unique prefix of ID")
+	}
+
+	if d, candidates := findSelectedDesktop("pla", desktops); d != nil || len(candidates) != 2 {
+		t.Error("TestFindSelectedDesktop: ambiguous prefix should return candidates")
+	}
+
+	if d, candidates := findSelectedDesktop("gnome", desktops); d != nil || len(candidates) != 0 {
+		t.Error("TestFindSelectedDesktop: no desktop should be found")
+	}
+}
+
+func TestConsoleEnv(t *testing.T) {
+	if parseEnv("console", "xorg") != Console || parseEnv("TTY", "xorg") != Console {
+		t.Error("TestConsoleEnv: console environment should be parsed")
+	}
+
+	if Console.stringify() != constEnvConsole || Console.string() != constEnvSConsole || Console.sessionType() != "tty" {
+		t.Error("TestConsoleEnv: wrong string values of console environment")
+	}
+
+	d := getConsoleDesktop(&config{ConsoleCommand: "tmux"})
+	if d.env != Console || d.envOrigin != Console || d.exec != "tmux" || d.id != constEnvConsole {
+		t.Error("TestConsoleEnv: wrong built-in console desktop")
+	}
+}
+
+func TestListCustomSessionScripts(t *testing.T) {
+	desktops := listDesktops(UserCustom, getTestingPath("custom-scripts"))
+	if len(desktops) != 3 {
+		t.Errorf("TestListCustomSessionScripts: unexpected count of desktops %d, 3 expected", len(desktops))
+	}
+
+	for _, d := range desktops {
+		switch d.id {
+		case "my-sway.sh":
+			if d.name != "My Sway" || d.env != Wayland || d.desktopNames != "sway:custom" {
+				t.Error("TestListCustomSessionScripts: headers of script were not parsed")
+			}
+		case "openbox-session":
+			if d.name != "openbox-session" || d.env != defaultEnvValue || d.envOrigin != UserCustom {
+				t.Error("TestListCustomSessionScripts: name should be taken from filename")
+			}
+		case "nested-tmux":
+			if d.name != "Tmux" || d.env != Console {
+				t.Error("TestListCustomSessionScripts: only headers before commands should be parsed")
+			}
+		default:
+			t.Errorf("TestListCustomSessionScripts: unexpected desktop '%s'", d.id)
+		}
+
+		if args := d.getExecArgs(); len(args) != 1 || args[0] != d.path || d.exec != d.path {
+			t.Error("TestListCustomSessionScripts: script should be started directly")
+		}
+	}
+
+	if len(listDesktops(Xorg, getTestingPath("custom-scripts"))) != 0 {
+		t.Error("TestListCustomSessionScripts: scripts should be used only for custom sessions")
+	}
+}
+
+func TestDesktopEnvVarsAndHooks(t *testing.T) {
+	d := getDesktop(getTestingPath("session-hooks/hooks.desktop"), Custom)
+
+	if d.envVars["QT_QPA_PLATFORM"] != "wayland" || d.envVars["Gtk_Theme"] != "Adwaita dark" || len(d.envVars) != 2 {
+		t.Errorf("TestDesktopEnvVarsAndHooks: unexpected environment variables %v", d.envVars)
+	}
+
+	if strings.Join(d.preExec, "|") != "/usr/bin/pre-hook|first arg|second" {
+		t.Errorf("TestDesktopEnvVarsAndHooks: unexpected PreExec %v", d.preExec)
+	}
+
+	if strings.Join(d.postExec, "|") != "/usr/bin/post-hook" {
+		t.Errorf("TestDesktopEnvVarsAndHooks: unexpected PostExec %v", d.postExec)
+	}
+
+	u, _ := loadUserDesktop(getTestingPath("userHome"))
+	if u.envVars["MOZ_ENABLE_WAYLAND"] != "1" || len(u.envVars) != 1 {
+		t.Errorf("TestDesktopEnvVarsAndHooks: unexpected user environment variables %v", u.envVars)
+	}
+}
+
+func TestLoadUserProfiles(t *testing.T) {
+	if d, _ := loadUserDesktop(getTestingPath("userHome7")); d != nil {
+		t.Error("TestLoadUserProfiles: configuration with profiles only should not be handled as user desktop")
+	}
+
+	var profiles []*desktop
+	readOutput(func() {
+		profiles = loadUserProfiles(getTestingPath("userHome7"))
+	})
+	if len(profiles) != 2 {
+		t.Errorf("TestLoadUserProfiles: unexpected count of profiles %d", len(profiles))
+		return
+	}
+
+	work := profiles[0]
+	if work.id != "profile:work" || work.name != "Work Desktop" || work.env != Wayland || work.envOrigin != UserCustom {
+		t.Error("TestLoadUserProfiles: unexpected work profile")
+	}
+	if work.lang != "de_DE.UTF-8" || work.envVars["MOZ_ENABLE_WAYLAND"] != "1" {
+		t.Error("TestLoadUserProfiles: unexpected LANG or environment variables of work profile")
+	}
+	if work.envVars["http_proxy"] != "http://proxy:3128" || work.envVars["no_proxy"] != "localhost" || work.envVars["HTTP_PROXY"] != "" {
+		t.Errorf("TestLoadUserProfiles: name of environment variable should keep its case %v", work.envVars)
+	}
+	if strings.Join(work.getArgs(), "|") != "/usr/bin/dbus-run-session|/usr/bin/sway|--unsupported-gpu" {
+		t.Errorf("TestLoadUserProfiles: unexpected exec of work profile %v", work.getArgs())
+	}
+
+	gaming := profiles[1]
+	if gaming.name != "gaming" || gaming.env != Xorg || gaming.lang != "" {
+		t.Error("TestLoadUserProfiles: unexpected gaming profile")
+	}
+
+	if profiles = loadUserProfiles(getTestingPath("userHome")); len(profiles) != 0 {
+		t.Error("TestLoadUserProfiles: configuration without sections should not have any profile")
+	}
+}
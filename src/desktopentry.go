@@ -0,0 +1,290 @@
+package src
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+)
+
+const desktopEntryGroup = "Desktop Entry"
+
+// desktopLocale defines locale used for resolving of localized keys in desktop files.
+var desktopLocale = ""
+
+// desktopEntryValue defines single key-value pair of desktop entry with key in its original form.
+type desktopEntryValue struct {
+	key   string
+	value string
+}
+
+// desktopEntry defines main group of freedesktop desktop file.
+type desktopEntry struct {
+	path   string
+	values []desktopEntryValue
+}
+
+// Sets locale used for resolving of localized keys in desktop files.
+func setDesktopLocale(lang string) {
+	desktopLocale = lang
+}
+
+// Reads main group of desktop file on path. If file does not contain any group header at all, all keys are handled
+// as part of main group to keep support of simplified custom sessions. Otherwise keys before first group header are ignored.
+func readDesktopEntry(path string) (*desktopEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New("Could not open file " + path)
+	}
+	defer file.Close()
+
+	e := &desktopEntry{path: path}
+	var headerless []desktopEntryValue
+	foundGroup := false
+	inMainGroup := false
+	foundMainGroup := false
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			group := line[1 : len(line)-1]
+			foundGroup = true
+			inMainGroup = group == desktopEntryGroup && !foundMainGroup
+			foundMainGroup = foundMainGroup || inMainGroup
+			continue
+		}
+
+		i := strings.Index(line, "=")
+		if i <= 0 {
+			continue
+		}
+		value := desktopEntryValue{strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])}
+		if !foundGroup {
+			headerless = append(headerless, value)
+		} else if inMainGroup {
+			e.values = append(e.values, value)
+		}
+	}
+
+	if !foundGroup {
+		e.values = headerless
+	}
+	return e, scanner.Err()
+}
+
+// Gets raw value of key, keys are compared case-insensitively to keep compatibility with older custom sessions.
+func (e *desktopEntry) getRaw(key string) (string, bool) {
+	for i := len(e.values) - 1; i >= 0; i-- {
+		if strings.EqualFold(e.values[i].key, key) {
+			return e.values[i].value, true
+		}
+	}
+	return "", false
+}
+
+// Gets unescaped string value of key.
+func (e *desktopEntry) getString(key string) string {
+	value, _ := e.getRaw(key)
+	return unescapeDesktopValue(value)
+}
+
+// Gets localized string value of key according to locale in form lang_COUNTRY.ENCODING@MODIFIER.
+func (e *desktopEntry) getLocaleString(key, locale string) string {
+	for _, l := range getLocaleVariants(locale) {
+		if value, exists := e.getRaw(key + "[" + l + "]"); exists {
+			return unescapeDesktopValue(value)
+		}
+	}
+	return e.getString(key)
+}
+
+// Gets boolean value of key.
+func (e *desktopEntry) getBool(key string) bool {
+	return parseBool(e.getString(key), constFalse)
+}
+
+// Gets list of strings separated by semicolon.
+func (e *desktopEntry) getStrings(key string) []string {
+	value, _ := e.getRaw(key)
+
+	var result []string
+	var sb strings.Builder
+	escapeNext := false
+	for _, r := range value {
+		switch {
+		case escapeNext:
+			if r == ';' {
+				sb.WriteRune(r)
+			} else {
+				sb.WriteRune('\\')
+				sb.WriteRune(r)
+			}
+			escapeNext = false
+		case r == '\\':
+			escapeNext = true
+		case r == ';':
+			if sb.Len() > 0 {
+				result = append(result, unescapeDesktopValue(sb.String()))
+			}
+			sb.Reset()
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() > 0 {
+		result = append(result, unescapeDesktopValue(sb.String()))
+	}
+	return result
+}
+
+// Gets variants of locale in order of matching defined by specification.
+func getLocaleVariants(locale string) []string {
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return nil
+	}
+
+	modifier := ""
+	if i := strings.Index(locale, "@"); i > -1 {
+		modifier = locale[i:]
+		locale = locale[:i]
+	}
+	if i := strings.Index(locale, "."); i > -1 {
+		locale = locale[:i]
+	}
+
+	lang, country := locale, ""
+	if i := strings.Index(locale, "_"); i > -1 {
+		lang, country = locale[:i], locale[i:]
+	}
+
+	var result []string
+	if country != "" && modifier != "" {
+		result = append(result, lang+country+modifier)
+	}
+	if country != "" {
+		result = append(result, lang+country)
+	}
+	if modifier != "" {
+		result = append(result, lang+modifier)
+	}
+	return append(result, lang)
+}
+
+// Unescapes value according to specification, supported escape sequences are \s, \n, \t, \r and \\.
+func unescapeDesktopValue(value string) string {
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+
+	var sb strings.Builder
+	escapeNext := false
+	for _, r := range value {
+		if escapeNext {
+			switch r {
+			case 's':
+				sb.WriteRune(' ')
+			case 'n':
+				sb.WriteRune('\n')
+			case 't':
+				sb.WriteRune('\t')
+			case 'r':
+				sb.WriteRune('\r')
+			case '\\':
+				sb.WriteRune('\\')
+			default:
+				sb.WriteRune('\\')
+				sb.WriteRune(r)
+			}
+			escapeNext = false
+			continue
+		}
+		if r == '\\' {
+			escapeNext = true
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	if escapeNext {
+		sb.WriteRune('\\')
+	}
+	return sb.String()
+}
+
+// Parses already unescaped Exec value into arguments according to quoting rules of specification.
+// Field codes are expanded, file and URL codes are removed, since session is started without any file.
+func parseDesktopExec(exec, name, icon, path string) ([]string, error) {
+	var result []string
+	var sb strings.Builder
+	inQuotes := false
+	escapeNext := false
+	fieldCode := false
+	hasArg := false
+
+	appendArg := func() {
+		if hasArg {
+			result = append(result, sb.String())
+		}
+		sb.Reset()
+		hasArg = false
+	}
+
+	for _, r := range exec {
+		switch {
+		case escapeNext:
+			sb.WriteRune(r)
+			escapeNext = false
+		case inQuotes && r == '\\':
+			escapeNext = true
+		case r == '"':
+			inQuotes = !inQuotes
+			hasArg = true
+		case inQuotes:
+			sb.WriteRune(r)
+		case fieldCode:
+			fieldCode = false
+			switch r {
+			case '%':
+				sb.WriteRune('%')
+				hasArg = true
+			case 'c':
+				sb.WriteString(name)
+				hasArg = true
+			case 'k':
+				sb.WriteString(path)
+				hasArg = true
+			case 'i':
+				if icon != "" && sb.Len() == 0 {
+					result = append(result, "--icon")
+					sb.WriteString(icon)
+					hasArg = true
+				}
+			case 'f', 'F', 'u', 'U', 'd', 'D', 'n', 'N', 'v', 'm':
+				// Codes for files, URLs and deprecated codes are removed
+			default:
+				return nil, errors.New("unknown field code %" + string(r) + " in Exec")
+			}
+		case r == '%':
+			fieldCode = true
+		case r == ' ' || r == '\t':
+			appendArg()
+		default:
+			sb.WriteRune(r)
+			hasArg = true
+		}
+	}
+
+	if inQuotes {
+		return nil, errors.New("unterminated quote in Exec")
+	}
+	appendArg()
+
+	if len(result) == 0 {
+		return nil, errors.New("empty Exec")
+	}
+	return result, nil
+}
@@ -0,0 +1,146 @@
+package src
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadDesktopEntry(t *testing.T) {
+	e, err := readDesktopEntry(getTestingPath("desktop-entries/spec.desktop"))
+	if err != nil {
+		t.Error("TestReadDesktopEntry: could not read desktop entry")
+		return
+	}
+
+	if e.getString(desktopName) != "Spec Session" {
+		t.Error("TestReadDesktopEntry: Name should be read only from main group")
+	}
+
+	if e.getString("Comment") != "Session with # inside value" {
+		t.Error("TestReadDesktopEntry: hash inside value should not be handled as comment")
+	}
+
+	if e.getString("X-Escaped") != "line one\nline\ttwo\\" {
+		t.Error("TestReadDesktopEntry: value was not correctly unescaped")
+	}
+
+	if strings.Join(e.getStrings("X-List"), ":") != "one;two:three" {
+		t.Error("TestReadDesktopEntry: list value was not correctly parsed")
+	}
+
+	if _, exists := e.getRaw("Missing"); exists {
+		t.Error("TestReadDesktopEntry: missing key should not exist")
+	}
+
+	e, err = readDesktopEntry(getTestingPath("desktops/desktop1.desktop"))
+	if err != nil || e.getString(desktopExec) != "/usr/bin/desktop1" {
+		t.Error("TestReadDesktopEntry: file without group header should be handled as main group")
+	}
+
+	e, err = readDesktopEntry(getTestingPath("desktop-entry-name-before-group"))
+	if err != nil || e.getString(desktopExec) != "/usr/bin/before-group" {
+		t.Error("TestReadDesktopEntry: main group should be read after keys before group header")
+	}
+	if _, exists := e.getRaw(desktopName); exists {
+		t.Error("TestReadDesktopEntry: key before group header should be ignored")
+	}
+
+	if _, err = readDesktopEntry(getTestingPath("desktop-entries/missing.desktop")); err == nil {
+		t.Error("TestReadDesktopEntry: missing file should return error")
+	}
+}
+
+func TestDesktopEntryLocaleString(t *testing.T) {
+	e, _ := readDesktopEntry(getTestingPath("desktop-entries/spec.desktop"))
+
+	expected := map[string]string{
+		"":                 "Spec Session",
+		"C":                "Spec Session",
+		"en_US.UTF-8":      "Spec Session",
+		"de_AT.UTF-8":      "Spec Sitzung",
+		"de_DE.UTF-8@euro": "Spec Sitzung Euro",
+		"cs_CZ.UTF-8":      "Spec Relace",
+		"cs":               "Spec Session",
+	}
+	for locale, name := range expected {
+		if value := e.getLocaleString(desktopName, locale); value != name {
+			t.Errorf("TestDesktopEntryLocaleString: locale '%s' resolved to '%s', expected '%s'", locale, value, name)
+		}
+	}
+}
+
+func TestGetLocaleVariants(t *testing.T) {
+	if strings.Join(getLocaleVariants("sr_YU.UTF-8@Latn"), ",") != "sr_YU@Latn,sr_YU,sr@Latn,sr" {
+		t.Error("TestGetLocaleVariants: unexpected variants for full locale")
+	}
+
+	if strings.Join(getLocaleVariants("sr"), ",") != "sr" {
+		t.Error("TestGetLocaleVariants: unexpected variants for language only")
+	}
+
+	if getLocaleVariants("POSIX") != nil {
+		t.Error("TestGetLocaleVariants: POSIX locale should not have any variants")
+	}
+}
+
+func TestParseDesktopExec(t *testing.T) {
+	args, err := parseDesktopExec(`"/opt/spec session/bin/start" --name %c --config "$HOME/a b.conf" %U --literal=100%% %i`, "Spec", "spec", "/tmp/spec.desktop")
+	expected := []string{"/opt/spec session/bin/start", "--name", "Spec", "--config", "$HOME/a b.conf", "--literal=100%", "--icon", "spec"}
+	if err != nil || strings.Join(args, "|") != strings.Join(expected, "|") {
+		t.Errorf("TestParseDesktopExec: unexpected arguments %q", args)
+	}
+
+	args, _ = parseDesktopExec("sh -c \"echo \\\"quoted\\\" \\$HOME \\`date\\`\" %k %i", "", "", "/tmp/spec.desktop")
+	expected = []string{"sh", "-c", "echo \"quoted\" $HOME `date`", "/tmp/spec.desktop"}
+	if strings.Join(args, "|") != strings.Join(expected, "|") {
+		t.Errorf("TestParseDesktopExec: unexpected arguments %q", args)
+	}
+
+	args, _ = parseDesktopExec(`app "" %f`, "", "", "")
+	if len(args) != 2 || args[1] != "" {
+		t.Errorf("TestParseDesktopExec: empty quoted argument should be kept %q", args)
+	}
+
+	if _, err = parseDesktopExec(`app "unterminated`, "", "", ""); err == nil {
+		t.Error("TestParseDesktopExec: unterminated quote should return error")
+	}
+
+	if _, err = parseDesktopExec(`app %x`, "", "", ""); err == nil {
+		t.Error("TestParseDesktopExec: unknown field code should return error")
+	}
+
+	if _, err = parseDesktopExec(`%U`, "", "", ""); err == nil {
+		t.Error("TestParseDesktopExec: empty Exec should return error")
+	}
+}
+
+func TestGetDesktopSpec(t *testing.T) {
+	setDesktopLocale("cs_CZ.UTF-8")
+	defer setDesktopLocale("")
+
+	path := getTestingPath("desktop-entries/spec.desktop")
+	d := getDesktop(path, Wayland)
+
+	if d.name != "Spec Relace" {
+		t.Error("TestGetDesktopSpec: wrong localized name")
+	}
+
+	if d.desktopNames != "Spec:SPEC" {
+		t.Error("TestGetDesktopSpec: wrong desktop names")
+	}
+
+	args := d.getExecArgs()
+	if len(args) != 8 || args[0] != "/opt/spec session/bin/start" || args[2] != "Spec Relace" {
+		t.Errorf("TestGetDesktopSpec: unexpected exec arguments %q", args)
+	}
+
+	cmd := cmdArgsAsUser(&sysuser{}, args)
+	if cmd.Path != "/opt/spec session/bin/start" || len(cmd.Args) != 8 {
+		t.Error("TestGetDesktopSpec: command should be prepared without re-parsing")
+	}
+
+	d = getDesktop(getTestingPath("desktops/desktop1.desktop"), Custom)
+	if strings.Join(d.getExecArgs(), " ") != "/usr/bin/desktop1" {
+		t.Error("TestGetDesktopSpec: wrong exec arguments of simple desktop")
+	}
+}
@@ -0,0 +1,110 @@
+package src
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+)
+
+// asUserHandle defines authHandle, that only looks up user without any authentication. It is allowed only for dry run.
+type asUserHandle struct {
+	*authBase
+	u *sysuser
+}
+
+// Creates handle of user defined by --as-user, that could be used only by root.
+func newAsUserAuth() *asUserHandle {
+	return &asUserHandle{authBase: &authBase{}}
+}
+
+// Gets sysuser
+func (h *asUserHandle) usr() *sysuser {
+	return h.u
+}
+
+// Looks up user without authentication.
+func (h *asUserHandle) authUser(conf *config) {
+	if !conf.DryRun || os.Getuid() != 0 {
+		handleStrErr("--as-user could be used only by root with dry run")
+	}
+	usr, err := user.Lookup(conf.AsUser)
+	handleErr(err)
+	h.u = getSysuser(usr)
+}
+
+// Nothing to close
+func (h *asUserHandle) closeAuth() {
+	// nothing to do
+}
+
+// Nothing to define
+func (h *asUserHandle) defineSpecificEnvVariables() {
+	// nothing to do
+}
+
+// Nothing to open
+func (h *asUserHandle) openAuthSession(sessionType string) error {
+	return nil
+}
+
+// Prints command, X server, hooks and environment of session without starting Xorg, dbus or the session itself.
+func (s *commonSession) printDryRun(w io.Writer) {
+	s.defineEnvironment()
+	if !s.conf.NoXdgFallback {
+		s.auth.usr().setenv(envXdgSessionType, s.d.env.sessionType())
+	}
+	if s.conf.AlwaysDbusLaunch {
+		s.dbus = &dbus{}
+	}
+
+	x, isXorg := s.session.(*xorgSession)
+	if isXorg {
+		x.defineXorgEnvironment()
+	}
+
+	cmd, strExec := s.prepareGuiCommand()
+	started := getStartedDesktop(s.d)
+
+	fmt.Fprintf(w, "Session: %s (%s)\n", started.name, s.d.env.stringify())
+	fmt.Fprintf(w, "Exec: %s\n", strExec)
+	fmt.Fprintf(w, "Command: %s\n", formatDryRunArgs(cmd.Args))
+	if isXorg {
+		xServer := x.getXServer()
+		fmt.Fprintf(w, "X server: %s\n", formatDryRunArgs(append([]string{xServer}, x.buildXorgArgs(xServer)...)))
+	}
+	fmt.Fprintf(w, "Dbus launch: %t\n", s.dbus != nil)
+
+	for _, d := range []*desktop{s.d.child, s.d} {
+		if d == nil {
+			continue
+		}
+		if len(d.preExec) > 0 {
+			fmt.Fprintf(w, "PreExec: %s\n", formatDryRunArgs(d.preExec))
+		}
+		if len(d.postExec) > 0 {
+			fmt.Fprintf(w, "PostExec: %s\n", formatDryRunArgs(d.postExec))
+		}
+	}
+
+	environ := s.auth.usr().environ()
+	sort.Strings(environ)
+	fmt.Fprintln(w, "Environment:")
+	for _, e := range environ {
+		fmt.Fprintf(w, "  %s\n", e)
+	}
+}
+
+// Formats arguments to be printed, arguments with whitespaces or quotes are quoted.
+func formatDryRunArgs(args []string) string {
+	var result []string
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\") {
+			arg = fmt.Sprintf("%q", arg)
+		}
+		result = append(result, arg)
+	}
+	return strings.Join(result, " ")
+}
@@ -31,11 +31,18 @@ Options:
   -t, --tty NUMBER		overrides configured TTY number
   -u, --default-user USER_NAME	overrides configured Default User
   -a, --autologin [SESSION]	overrides configured autologin to true and if next argument is defined, it defines also Autologin Session
+  -S, --session-stats [USER_NAME]	prints session usage statistics of user or of all users
+  -L, --list-sessions		prints sessions, that would be offered to user, could be combined with:
+      --user USER_NAME		  lists sessions of defined user instead of current user
+      --json			  prints sessions as JSON
+  -n, --dry-run			authenticates user, selects session and prints its command and environment without starting it
+      --as-user USER_NAME	dry run as defined user without authentication, allowed only for root
 `
 )
 
 var buildVersion string
 var errPrintCommandHelp = errors.New("just print help")
+var interruptChan chan os.Signal
 
 type sessionHandle struct {
 	session     *commonSession
@@ -54,11 +61,23 @@ func Main() {
 	processCoreArgs(os.Args)
 
 	conf := loadConfig(loadConfigPath(os.Args))
+	cmdlineParams, ignoredCmdlineParams := applyKernelCmdline(conf, readKernelCmdline(pathKernelCmdline), !contains(os.Args, "-t", "--tty"))
 	processArgs(os.Args, conf)
 
+	if conf.DryRun {
+		login(conf, &sessionHandle{})
+		return
+	}
+
 	fTTY := startDaemon(conf)
 
 	initLogger(conf)
+	if len(cmdlineParams) > 0 {
+		logPrint("Applied kernel command line parameters: " + strings.Join(cmdlineParams, " "))
+	}
+	if len(ignoredCmdlineParams) > 0 {
+		logPrint("Ignored kernel command line parameters, they could not enable autologin, change its user or TTY defined by argument: " + strings.Join(ignoredCmdlineParams, " "))
+	}
 	printMotd(conf)
 
 	if command := login(conf, initSessionHandle()); command != "" {
@@ -74,11 +93,25 @@ func initSessionHandle() *sessionHandle {
 
 	c := make(chan os.Signal, 10)
 	signal.Notify(c, os.Interrupt, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
+	interruptChan = c
 	go handleInterrupt(c, h)
 
 	return h
 }
 
+// Runs method with ignored SIGHUP, then SIGHUP is reset to default and handled by interrupt handler again.
+// Started processes do not inherit ignored SIGHUP.
+func ignoreHangup(method func()) {
+	signal.Ignore(syscall.SIGHUP)
+	defer func() {
+		signal.Reset(syscall.SIGHUP)
+		if interruptChan != nil {
+			signal.Notify(interruptChan, syscall.SIGHUP)
+		}
+	}()
+	method()
+}
+
 // Catch interrupt signal chan and interrupts Cmd.
 func handleInterrupt(c chan os.Signal, h *sessionHandle) {
 	<-c
@@ -139,6 +172,10 @@ func loadConfigPath(args []string) (configPath string) {
 // Process arguments with affection on configuration
 func processArgs(args []string, conf *config) {
 	printConfig := false
+	printStats := false
+	statsUser := ""
+	printSessions := false
+	listUser := ""
 
 	for i, arg := range args {
 		switch arg {
@@ -170,6 +207,24 @@ func processArgs(args []string, conf *config) {
 			})
 		case "-C", "--print-config":
 			printConfig = true
+		case "-S", "--session-stats":
+			printStats = true
+			nextArg(args, i, func(val string) {
+				statsUser = val
+			})
+		case "-L", "--list-sessions":
+			printSessions = true
+		case "-n", "--dry-run":
+			conf.DryRun = true
+		case "--as-user":
+			conf.DryRun = true
+			nextArg(args, i, func(val string) {
+				conf.AsUser = val
+			})
+		case "--user":
+			nextArg(args, i, func(val string) {
+				listUser = val
+			})
 		}
 	}
 
@@ -177,6 +232,16 @@ func processArgs(args []string, conf *config) {
 		conf.printConfig()
 		os.Exit(0)
 	}
+
+	if printStats {
+		printSessionStats(statsUser)
+		os.Exit(0)
+	}
+
+	if printSessions {
+		printSessionList(conf, listUser, contains(args, "--json"))
+		os.Exit(0)
+	}
 }
 
 // Gets next argument, if available
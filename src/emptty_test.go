@@ -1,8 +1,12 @@
 package src
 
 import (
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestGetVersion(t *testing.T) {
@@ -128,3 +132,33 @@ func TestLoadConfigPath(t *testing.T) {
 		t.Errorf("TestLoadConfigPath: no path was expected, but was '%s'", path)
 	}
 }
+
+func TestIgnoreHangup(t *testing.T) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	interruptChan = c
+	defer func() {
+		signal.Reset(syscall.SIGHUP)
+		interruptChan = nil
+	}()
+
+	ignoreHangup(func() {
+		syscall.Kill(os.Getpid(), syscall.SIGHUP)
+	})
+	select {
+	case <-c:
+		t.Error("TestIgnoreHangup: hangup should be ignored")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if signal.Ignored(syscall.SIGHUP) {
+		t.Error("TestIgnoreHangup: hangup should not stay ignored")
+	}
+
+	syscall.Kill(os.Getpid(), syscall.SIGHUP)
+	select {
+	case <-c:
+	case <-time.After(time.Second):
+		t.Error("TestIgnoreHangup: hangup should be handled again")
+	}
+}
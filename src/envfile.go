@@ -0,0 +1,117 @@
+package src
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	pathSystemEnvironment = "/etc/environment"
+	pathEmpttyEnvironment = "/etc/emptty/environment"
+	pathUserEnvironmentD  = "environment.d"
+)
+
+// Environmental variables, that could not be overridden by environment files. Paths of XDG_RUNTIME_DIR, PWD and XAUTHORITY
+// are used by emptty running as root, so they could not be redirected by user.
+var protectedEnvKeys = []string{envHome, envUser, envLogname, envUid, envShell, envXdgRuntimeDir, envPwd, envXauthority}
+
+// Defines environmental variables from environment files in following order, where later value overrides previous one:
+// system paths (e.g. /etc/environment and /etc/emptty/environment) and then *.conf files from user's environment.d sorted by name.
+// User's files are read with user's permissions.
+func defineEnvironmentFromFiles(usr *sysuser, systemPaths []string, userDir string) {
+	loadEnvironmentFiles(usr, systemPaths...)
+
+	doAsUser(usr, func() {
+		loadEnvironmentFiles(usr, listEnvironmentD(userDir)...)
+	})
+}
+
+// Gets path to user's environment.d directory.
+func getUserEnvironmentDir(usr *sysuser) string {
+	configHome := usr.getenv(envXdgConfigHome)
+	if configHome == "" {
+		configHome = filepath.Join(usr.homedir, ".config")
+	}
+	return filepath.Join(configHome, pathUserEnvironmentD)
+}
+
+// Lists all *.conf files in environment.d directory sorted by name.
+func listEnvironmentD(dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*.conf"))
+	if err != nil {
+		logPrint(err)
+		return nil
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Loads environment files in defined order into user's environment.
+func loadEnvironmentFiles(usr *sysuser, paths ...string) {
+	for _, path := range paths {
+		if !fileExists(path) {
+			continue
+		}
+		if err := readEnvironmentFile(path, func(key, value string) {
+			if contains(protectedEnvKeys, key) {
+				logPrintf("Environment file %s could not override %s", path, key)
+				return
+			}
+			usr.setenv(key, expandEnvValue(value, usr))
+		}); err != nil {
+			logPrint(err)
+		}
+	}
+}
+
+// Reads environment file per line and parses each key-value pair. Unlike readProperties, keys are case-sensitive
+// and "#" is handled as comment only at the start of line.
+func readEnvironmentFile(path string, method propertyFunc) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || !strings.Contains(line, "=") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		splitIndex := strings.Index(line, "=")
+		key := strings.TrimSpace(line[:splitIndex])
+		value := strings.TrimSpace(line[splitIndex+1:])
+		if len(value) > 1 && ((strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"")) || (strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'"))) {
+			value = value[1 : len(value)-1]
+		}
+		if key != "" && !strings.ContainsAny(key, " \t") {
+			method(key, value)
+		}
+	}
+	return scanner.Err()
+}
+
+// Expands $VAR, ${VAR}, ${VAR:-default} and ${VAR:+alternative} with values from user's environment.
+func expandEnvValue(value string, usr *sysuser) string {
+	return os.Expand(value, func(name string) string {
+		if i := strings.Index(name, ":-"); i > -1 {
+			if v := usr.getenv(name[:i]); v != "" {
+				return v
+			}
+			return name[i+2:]
+		}
+		if i := strings.Index(name, ":+"); i > -1 {
+			if usr.getenv(name[:i]) != "" {
+				return name[i+2:]
+			}
+			return ""
+		}
+		return usr.getenv(name)
+	})
+}
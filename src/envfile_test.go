@@ -0,0 +1,60 @@
+package src
+
+import (
+	"os/user"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefineEnvironmentFromFiles(t *testing.T) {
+	currentUser, _ := user.Current()
+	usr := getSysuser(currentUser)
+	usr.homedir = getTestingPath("userHome4")
+	usr.setenv(envHome, usr.homedir)
+	usr.setenv(envPath, "/usr/bin")
+
+	defineEnvironmentFromFiles(usr, []string{getTestingPath("environment"), getTestingPath("emptty-environment"), getTestingPath("non-existing-environment")}, getUserEnvironmentDir(usr))
+
+	expected := map[string]string{
+		envHome:     usr.homedir,
+		"EDITOR":    "vim",
+		"BROWSER":   "firefox-esr",
+		"MY_PATH":   "/opt/bin:/usr/bin",
+		"TOOLS":     usr.homedir + "/.local/bin:/opt/bin:/usr/bin",
+		"PAGER":     "less",
+		"HAS_PAGER": "yes",
+		"COLOR":     "#ff0000",
+	}
+	for key, value := range expected {
+		if usr.getenv(key) != value {
+			t.Errorf("TestDefineEnvironmentFromFiles: %s has unexpected value '%s', expected '%s'", key, usr.getenv(key), value)
+		}
+	}
+}
+
+func TestListEnvironmentD(t *testing.T) {
+	files := listEnvironmentD(getTestingPath("userHome4/.config/environment.d"))
+	if len(files) != 2 {
+		t.Fatalf("TestListEnvironmentD: 2 files were expected, but was %d", len(files))
+	}
+
+	if filepath.Base(files[0]) != "10-editor.conf" {
+		t.Errorf("TestListEnvironmentD: unexpected order of files '%s'", files[0])
+	}
+
+	if len(listEnvironmentD(getTestingPath("non-existing-dir"))) != 0 {
+		t.Error("TestListEnvironmentD: no file was expected")
+	}
+}
+
+func TestGetUserEnvironmentDir(t *testing.T) {
+	usr := &sysuser{homedir: "/home/emptty", env: make(map[string]string)}
+	if dir := getUserEnvironmentDir(usr); dir != "/home/emptty/.config/environment.d" {
+		t.Errorf("TestGetUserEnvironmentDir: unexpected dir '%s'", dir)
+	}
+
+	usr.setenv(envXdgConfigHome, "/tmp/config")
+	if dir := getUserEnvironmentDir(usr); dir != "/tmp/config/environment.d" {
+		t.Errorf("TestGetUserEnvironmentDir: unexpected dir '%s'", dir)
+	}
+}
@@ -0,0 +1,60 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultSessionPath   = "/usr/local/bin:/usr/bin:/bin"
+	defaultSessionSuPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+)
+
+// Checks, if name of environmental variable matches any of patterns.
+func matchEnvKey(key string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, err := filepath.Match(pattern, key); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks, if environmental variable of emptty could be passed further according to ENV_CLEAN, ENV_ALLOW and ENV_DENY.
+func isEnvKeyPassable(conf *config, key string) bool {
+	if matchEnvKey(key, conf.EnvDeny) {
+		return false
+	}
+	return !conf.EnvClean || matchEnvKey(key, conf.EnvAllow)
+}
+
+// Filters environment of emptty to be passed into Xorg and display scripts. Result is never nil to avoid
+// inheriting whole environment by exec.Cmd.
+func filterEnviron(conf *config, environ []string) []string {
+	result := []string{}
+	for _, e := range environ {
+		if i := strings.Index(e, "="); i > 0 && isEnvKeyPassable(conf, e[:i]) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Passes allowed environmental variables of emptty into user's environment, already defined values are kept.
+func passAllowedEnviron(conf *config, usr *sysuser) {
+	for _, e := range filterEnviron(conf, os.Environ()) {
+		i := strings.Index(e, "=")
+		if matchEnvKey(e[:i], conf.EnvAllow) {
+			usr.setenvIfEmpty(e[:i], e[i+1:])
+		}
+	}
+}
+
+// Gets default PATH, if emptty's own PATH is not allowed to be passed.
+func getDefaultSessionPath(uid int) string {
+	if uid == 0 {
+		return defaultSessionSuPath
+	}
+	return defaultSessionPath
+}
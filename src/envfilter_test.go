@@ -0,0 +1,81 @@
+package src
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchEnvKey(t *testing.T) {
+	patterns := []string{"NOTIFY_SOCKET", "LISTEN_*"}
+
+	if !matchEnvKey("NOTIFY_SOCKET", patterns) || !matchEnvKey("LISTEN_FDS", patterns) {
+		t.Error("TestMatchEnvKey: keys should match")
+	}
+
+	if matchEnvKey("NOTIFY_SOCKET_2", patterns) || matchEnvKey("PATH", patterns) || matchEnvKey("PATH", nil) {
+		t.Error("TestMatchEnvKey: keys should not match")
+	}
+}
+
+func TestFilterEnviron(t *testing.T) {
+	environ := []string{"PATH=/usr/bin", "NOTIFY_SOCKET=/run/systemd/notify", "INVOCATION_ID=123", "TERM=linux", "LISTEN_FDS=1"}
+	conf := loadConfig("")
+
+	result := strings.Join(filterEnviron(conf, environ), ";")
+	if result != "PATH=/usr/bin;TERM=linux" {
+		t.Errorf("TestFilterEnviron: unexpected result with default config '%s'", result)
+	}
+
+	conf.EnvClean = true
+	result = strings.Join(filterEnviron(conf, environ), ";")
+	if result != "" || filterEnviron(conf, environ) == nil {
+		t.Errorf("TestFilterEnviron: unexpected result with clean environment '%s'", result)
+	}
+
+	conf.EnvAllow = []string{"TERM", "NOTIFY_*"}
+	result = strings.Join(filterEnviron(conf, environ), ";")
+	if result != "TERM=linux" {
+		t.Errorf("TestFilterEnviron: unexpected result with allowed TERM '%s'", result)
+	}
+}
+
+func TestPassAllowedEnviron(t *testing.T) {
+	t.Setenv("EMPTTY_TEST_ALLOWED", "allowed")
+	t.Setenv("EMPTTY_TEST_DEFINED", "emptty")
+	t.Setenv("EMPTTY_TEST_DENIED", "denied")
+	t.Setenv("EMPTTY_TEST_OTHER", "other")
+
+	usr := &sysuser{env: make(map[string]string)}
+	usr.setenv("EMPTTY_TEST_DEFINED", "user")
+
+	conf := &config{EnvAllow: []string{"EMPTTY_TEST_ALLOWED", "EMPTTY_TEST_DEFINED", "EMPTTY_TEST_DENIED"}, EnvDeny: []string{"EMPTTY_TEST_DENIED"}}
+	passAllowedEnviron(conf, usr)
+
+	if usr.getenv("EMPTTY_TEST_ALLOWED") != "allowed" {
+		t.Error("TestPassAllowedEnviron: allowed variable was not passed")
+	}
+	if usr.getenv("EMPTTY_TEST_DEFINED") != "user" {
+		t.Error("TestPassAllowedEnviron: already defined variable was overridden")
+	}
+	if usr.getenv("EMPTTY_TEST_DENIED") != "" || usr.getenv("EMPTTY_TEST_OTHER") != "" {
+		t.Error("TestPassAllowedEnviron: not allowed variable was passed")
+	}
+}
+
+func TestGetSessionPathClean(t *testing.T) {
+	t.Setenv(envPath, "/emptty/bin")
+
+	conf := &config{EnvClean: true}
+	if path := getSessionPath(conf, &sysuser{uid: 1000}); path != defaultSessionPath {
+		t.Errorf("TestGetSessionPathClean: default PATH was expected, but was '%s'", path)
+	}
+
+	if path := getSessionPath(conf, &sysuser{uid: 0}); path != defaultSessionSuPath {
+		t.Errorf("TestGetSessionPathClean: default root PATH was expected, but was '%s'", path)
+	}
+
+	conf.EnvAllow = []string{envPath}
+	if path := getSessionPath(conf, &sysuser{uid: 1000}); path != "/emptty/bin" {
+		t.Errorf("TestGetSessionPathClean: PATH of emptty was expected, but was '%s'", path)
+	}
+}
@@ -5,15 +5,19 @@ import "strings"
 const (
 	constEnvXorg    = "xorg"
 	constEnvWayland = "wayland"
+	constEnvConsole = "console"
+	constEnvTty     = "tty"
 
 	constEnvSUndefined  = "Undefined"
 	constEnvSXorg       = "Xorg"
 	constEnvSWayland    = "Wayland"
 	constEnvSCustom     = "Custom"
 	constEnvSUserCustom = "User Custom"
+	constEnvSConsole    = "Console"
 
 	constEnvSTXorg    = "x11"
 	constEnvSTWayland = "wayland"
+	constEnvSTConsole = "tty"
 )
 
 // enEnvironment defines possible Environments.
@@ -36,6 +40,9 @@ const (
 
 	// UserCustom represents user's desktops, only helper before real env is loaded
 	UserCustom
+
+	// Console represents plain console session started directly on TTY
+	Console
 )
 
 // Returns default environment as string value.
@@ -50,6 +57,8 @@ func parseEnv(env, defaultValue string) enEnvironment {
 		return Xorg
 	case constEnvWayland:
 		return Wayland
+	case constEnvConsole, constEnvTty:
+		return Console
 	}
 	return defaultEnvValue
 }
@@ -61,18 +70,20 @@ func (e enEnvironment) stringify() string {
 		return constEnvXorg
 	case Wayland:
 		return constEnvWayland
+	case Console:
+		return constEnvConsole
 	}
 	return defaultEnv()
 }
 
 // String value of enEnvironment
 func (e enEnvironment) string() string {
-	strings := []string{constEnvSUndefined, constEnvSXorg, constEnvSWayland, constEnvSCustom, constEnvSUserCustom}
+	strings := []string{constEnvSUndefined, constEnvSXorg, constEnvSWayland, constEnvSCustom, constEnvSUserCustom, constEnvSConsole}
 	return strings[e]
 }
 
 // Session type of enEnvironment
 func (e enEnvironment) sessionType() string {
-	strings := []string{"", constEnvSTXorg, constEnvSTWayland, "", ""}
+	strings := []string{"", constEnvSTXorg, constEnvSTWayland, "", "", constEnvSTConsole}
 	return strings[e]
 }
@@ -2,10 +2,13 @@ package src
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 )
 
 const (
@@ -20,15 +23,32 @@ type authHandle interface {
 	defineSpecificEnvVariables()
 	openAuthSession(string) error
 	getCommand() string
+	getSession() string
 }
 
 // Login into graphical environment
 func login(conf *config, h *sessionHandle) string {
-	h.auth = auth(conf)
+	loginTimeout := startLoginTimeout(conf)
+	if conf.AsUser != "" {
+		h.auth = newAsUserAuth()
+	} else {
+		h.auth = newAuth()
+	}
+	h.auth.authUser(conf)
+	if loginTimeout != nil {
+		loginTimeout.Stop()
+	}
 	if h.auth != nil && h.auth.getCommand() != "" {
 		return h.auth.getCommand()
 	}
 
+	if conf.DryRun {
+		d := processDesktopSelection(h.auth, conf)
+		createSession(h.auth, d, conf).printDryRun(os.Stdout)
+		h.auth.closeAuth()
+		return ""
+	}
+
 	if err := handleLoginRetries(conf, &DefaultLoginRetryPathProvider{}); err != nil {
 		h.auth.closeAuth()
 		handleStrErr("Exceeded maximum number of allowed login retries in short period.")
@@ -40,7 +60,7 @@ func login(conf *config, h *sessionHandle) string {
 		return ""
 	}
 
-	runDisplayScript(conf.DisplayStartScript)
+	runDisplayScript(conf, conf.DisplayStartScript)
 
 	if err := h.auth.openAuthSession(d.env.sessionType()); err != nil {
 		h.auth.closeAuth()
@@ -48,33 +68,76 @@ func login(conf *config, h *sessionHandle) string {
 		return ""
 	}
 
-	h.session = createSession(h.auth, d, conf)
-	h.session.start()
+	if err := runSession(h, d, conf); isSessionErrShown(conf, d, err) {
+		handleErr(err)
+	}
 
 	h.auth.closeAuth()
 
-	runDisplayScript(conf.DisplayStopScript)
+	runDisplayScript(conf, conf.DisplayStopScript)
 
 	return ""
 }
 
+// Starts timer, that ends login prompt after LOGIN_TIMEOUT defined in login.defs. Timeout is handled as interrupt
+// to close authentication before exit.
+func startLoginTimeout(conf *config) *time.Timer {
+	timeout := conf.Defs.getLoginTimeout()
+	if timeout <= 0 || (conf.Autologin && conf.DefaultUser != "") {
+		return nil
+	}
+
+	return time.AfterFunc(time.Duration(timeout)*time.Second, func() {
+		logPrintf("Login timed out after %d seconds", timeout)
+		fmt.Printf("\n%sLogin timed out after %d seconds.\n", conf.GetIndentString(), timeout)
+		if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+			logPrint(err)
+			os.Exit(1)
+		}
+	})
+}
+
 // Process whole desktop load, selection and last used save.
 func processDesktopSelection(auth authHandle, conf *config) *desktop {
 	usr := auth.usr()
 	d, usrLang := loadUserDesktop(usr.homedir)
+	if usrLang != "" {
+		setDesktopLocale(usrLang)
+	} else {
+		setDesktopLocale(conf.Lang)
+	}
+
+	if d != nil && d.selection == SelectionFalse && auth.getSession() != "" {
+		logPrint("Session from login is ignored, selection is disabled by user configuration")
+	}
 
 	if d == nil || d.selection != SelectionFalse {
 		selectedDesktop, lastDesktop := selectDesktop(auth, conf, d)
-		if isLastDesktopForSave(usr, lastDesktop, selectedDesktop) {
-			setUserLastSession(usr, selectedDesktop)
+		if !conf.DryRun && isLastDesktopForSave(conf, usr, lastDesktop, selectedDesktop) {
+			setUserLastSession(conf, usr, selectedDesktop)
 		}
 
-		if d != nil && d.selection != SelectionFalse {
-			d.child = selectedDesktop
-			d.env = d.child.env
-		} else {
-			d = selectedDesktop
+		d = applySelectedDesktop(d, selectedDesktop)
+	}
+
+	for {
+		err := checkDesktopExec(conf, usr, d)
+		if err == nil {
+			break
 		}
+		logPrint(err)
+		fmt.Printf("\n%s%s\n", conf.GetIndentString(), err)
+		d = applySelectedDesktop(d, selectFallbackDesktop(auth, conf))
+	}
+
+	if started := getStartedDesktop(d); started.id != "" && !started.failsafe && !conf.DryRun {
+		updateSessionStats(usr, started, time.Now())
+	}
+
+	if d.child != nil && d.child.lang != "" {
+		usrLang = d.child.lang
+	} else if d.lang != "" {
+		usrLang = d.lang
 	}
 
 	if usrLang != "" {
@@ -84,11 +147,32 @@ func processDesktopSelection(auth authHandle, conf *config) *desktop {
 	return d
 }
 
+// Applies selected desktop as child of user desktop, if user desktop requires selection. Otherwise selected desktop is used.
+// Failsafe desktop is always used directly to avoid user configuration.
+func applySelectedDesktop(d *desktop, selected *desktop) *desktop {
+	if d != nil && d.selection != SelectionFalse && !selected.failsafe {
+		d.child = selected
+		d.env = d.child.env
+		return d
+	}
+	return selected
+}
+
+// Gets desktop, that is really started, it is selected child of user desktop, if user desktop requires selection.
+func getStartedDesktop(d *desktop) *desktop {
+	if d.selection != SelectionFalse && d.child != nil {
+		return d.child
+	}
+	return d
+}
+
 // Runs display script, if defined
-func runDisplayScript(scriptPath string) {
+func runDisplayScript(conf *config, scriptPath string) {
 	if scriptPath != "" {
 		if fileIsExecutable(scriptPath) {
-			if err := exec.Command(scriptPath).Run(); err != nil {
+			cmd := exec.Command(scriptPath)
+			cmd.Env = filterEnviron(conf, os.Environ())
+			if err := cmd.Run(); err != nil {
 				logPrint(err)
 			}
 		} else {
@@ -1,7 +1,10 @@
 package src
 
 import (
+	"bufio"
 	"os"
+	"os/user"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -101,3 +104,153 @@ func TestReadWriteRetryFile(t *testing.T) {
 
 	os.RemoveAll(retryPath)
 }
+
+func TestStartLoginTimeout(t *testing.T) {
+	if startLoginTimeout(&config{}) != nil {
+		t.Error("TestStartLoginTimeout: no timer was expected without LOGIN_TIMEOUT")
+	}
+
+	c := &config{Defs: &loginDefs{loginTimeout: 60}, Autologin: true, DefaultUser: "emptty"}
+	if startLoginTimeout(c) != nil {
+		t.Error("TestStartLoginTimeout: no timer was expected for autologin")
+	}
+
+	c.Autologin = false
+	timer := startLoginTimeout(c)
+	if timer == nil {
+		t.Error("TestStartLoginTimeout: timer was expected")
+	} else {
+		timer.Stop()
+	}
+}
+
+func TestProcessDesktopSelectionWithLoginSession(t *testing.T) {
+	t.Setenv(envXdgDataDirs, "/dev/null")
+	conf := &config{XorgSessionsPath: getTestingPath("desktops"), WaylandSessionsPath: "/dev/null", UnavailableSessions: UnavailableShow}
+	a := &testAuth{&authBase{session: "desktop2"}, &sysuser{homedir: getTestingPath("userHome2")}}
+
+	d, _ := selectDesktop(a, conf, nil)
+	if d == nil || d.name != "Desktop2" {
+		t.Error("TestProcessDesktopSelectionWithLoginSession: session from login should be selected")
+	}
+
+	binDir := t.TempDir()
+	os.WriteFile(filepath.Join(binDir, "none"), []byte("#!/bin/sh\n"), 0755)
+	conf.Defs = &loginDefs{envPath: binDir}
+
+	a.u.homedir = getTestingPath("userHome")
+	d = processDesktopSelection(a, conf)
+	if d == nil || d.name != "window-manager" || d.child != nil {
+		t.Error("TestProcessDesktopSelectionWithLoginSession: user configuration with disabled selection should be honoured")
+	}
+}
+
+func TestCheckDesktopExec(t *testing.T) {
+	conf := &config{Defs: &loginDefs{envPath: "/dev/null"}}
+	usr := &sysuser{uid: 1000}
+
+	if err := checkDesktopExec(conf, usr, &desktop{name: "missing", exec: "missing-binary --arg"}); err == nil {
+		t.Error("TestCheckDesktopExec: missing binary should not pass preflight check")
+	}
+
+	if err := checkDesktopExec(conf, usr, &desktop{exec: "/bin/sh -c true"}); err != nil {
+		t.Errorf("TestCheckDesktopExec: unexpected error %v", err)
+	}
+
+	child := &desktop{name: "child", exec: "missing-binary"}
+	if err := checkDesktopExec(conf, usr, &desktop{path: "/dev/null", selection: SelectionTrue, child: child}); err == nil {
+		t.Error("TestCheckDesktopExec: child of user configuration should be checked")
+	}
+
+	if err := checkDesktopExec(conf, usr, getConsoleDesktop(conf)); err != nil {
+		t.Errorf("TestCheckDesktopExec: built-in console should always pass, got %v", err)
+	}
+
+	if err := checkDesktopExec(conf, usr, &desktop{isUser: true, exec: "missing-binary", selection: SelectionFalse}); err == nil {
+		t.Error("TestCheckDesktopExec: missing binary of user configuration should not pass preflight check")
+	}
+
+	if err := checkDesktopExec(conf, usr, &desktop{isUser: true, loginShell: "/bin/sh -l", exec: "missing-binary", selection: SelectionFalse}); err == nil {
+		t.Error("TestCheckDesktopExec: command of user configuration started through login shell should be checked")
+	}
+
+	if err := checkDesktopExec(conf, usr, &desktop{isUser: true, loginShell: "missing-shell", exec: "/bin/sh -c true", selection: SelectionFalse}); err != nil {
+		t.Errorf("TestCheckDesktopExec: user configuration should be checked by its command, got %v", err)
+	}
+
+	if err := checkDesktopExec(conf, usr, &desktop{id: constProfileIdPrefix + "work", exec: "missing-binary"}); err == nil {
+		t.Error("TestCheckDesktopExec: missing binary of profile should not pass preflight check")
+	}
+}
+
+func TestCheckDesktopExecWithUserPath(t *testing.T) {
+	conf := &config{Defs: &loginDefs{envPath: "/dev/null"}}
+	currentUser, _ := user.Current()
+	usr := getSysuser(currentUser)
+	usr.homedir = t.TempDir()
+
+	for _, path := range []string{".config/environment.d/path.conf", "opt/bin/envd-session", ".local/bin/local-session"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(usr.homedir, path)), 0755)
+	}
+	os.WriteFile(filepath.Join(usr.homedir, ".config/environment.d/path.conf"), []byte("PATH=${HOME}/opt/bin:${PATH}\n"), 0644)
+	os.WriteFile(filepath.Join(usr.homedir, "opt/bin/envd-session"), []byte("#!/bin/sh\n"), 0755)
+	os.WriteFile(filepath.Join(usr.homedir, ".local/bin/local-session"), []byte("#!/bin/sh\n"), 0755)
+
+	if err := checkDesktopExec(conf, usr, &desktop{name: "envd", exec: "envd-session"}); err != nil {
+		t.Errorf("TestCheckDesktopExecWithUserPath: PATH from environment.d should be used, got %v", err)
+	}
+
+	if err := checkDesktopExec(conf, usr, &desktop{name: "local", exec: "local-session --arg"}); err != nil {
+		t.Errorf("TestCheckDesktopExecWithUserPath: ~/.local/bin should be used, got %v", err)
+	}
+
+	if err := checkDesktopExec(conf, usr, &desktop{name: "missing", exec: "missing-binary"}); err == nil {
+		t.Error("TestCheckDesktopExecWithUserPath: missing binary should not pass preflight check")
+	}
+
+	usr.lookPath = ""
+	conf.ImportShellEnv = true
+	conf.ImportShellEnvTime = 5
+	os.WriteFile(filepath.Join(usr.homedir, ".profile"), []byte("touch \"$HOME/profile-started\"\n"), 0644)
+	getUserSessionPath(conf, usr)
+	if fileExists(filepath.Join(usr.homedir, "profile-started")) {
+		t.Error("TestCheckDesktopExecWithUserPath: login shell should not be started to get PATH")
+	}
+}
+
+func TestSelectDesktopDefaultSessionNotFound(t *testing.T) {
+	t.Setenv(envXdgDataDirs, "/dev/null")
+	conf := &config{XorgSessionsPath: getTestingPath("desktops"), WaylandSessionsPath: "/dev/null", UnavailableSessions: UnavailableShow, DefaultSession: "missing", FailsafeCommand: "sh -l"}
+	a := &testAuth{&authBase{}, &sysuser{homedir: getTestingPath("userHome2")}}
+
+	origReader := stdinReader
+	defer func() { stdinReader = origReader }()
+	stdinReader = bufio.NewReader(strings.NewReader("failsafe\n"))
+
+	var d *desktop
+	readOutput(func() {
+		d, _ = selectDesktop(a, conf, nil)
+	})
+	if d == nil || !d.failsafe {
+		t.Error("TestSelectDesktopDefaultSessionNotFound: failsafe session should be available, if default session was not found")
+	}
+}
+
+func TestFailsafeDesktop(t *testing.T) {
+	conf := &config{FailsafeCommand: "sh -l"}
+
+	d := getFailsafeDesktop(conf, "/bin:/usr/bin")
+	if d.env != Xorg || d.exec != "sh -l" || !d.failsafe {
+		t.Error("TestFailsafeDesktop: failsafe command should be started under Xorg")
+	}
+
+	user := &desktop{path: "/dev/null", selection: SelectionTrue}
+	if applySelectedDesktop(user, d) != d || user.child != nil {
+		t.Error("TestFailsafeDesktop: failsafe desktop should not be started through user configuration")
+	}
+
+	d = getFailsafeDesktop(conf, "/dev/null")
+	if d.env != Console || d.exec != "" {
+		t.Error("TestFailsafeDesktop: console shell should be used, if failsafe command is not available")
+	}
+}
@@ -0,0 +1,172 @@
+package src
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	pathLoginDefs = "/etc/login.defs"
+	pathLocalBin  = ".local/bin"
+
+	loginDefsEnvPath      = "ENV_PATH"
+	loginDefsEnvSuPath    = "ENV_SUPATH"
+	loginDefsUmask        = "UMASK"
+	loginDefsFailDelay    = "FAIL_DELAY"
+	loginDefsLoginRetries = "LOGIN_RETRIES"
+	loginDefsLoginTimeout = "LOGIN_TIMEOUT"
+)
+
+// loginDefs defines structure of values used from login.defs.
+type loginDefs struct {
+	envPath      string
+	envSuPath    string
+	umask        int
+	failDelay    int
+	loginRetries int
+	loginTimeout int
+}
+
+// Creates loginDefs with default values, that keep the behaviour without login.defs.
+func newLoginDefs() *loginDefs {
+	return &loginDefs{umask: -1, loginRetries: 1}
+}
+
+// Reads login.defs from defined path. If file could not be read, default values are returned.
+func readLoginDefs(path string) *loginDefs {
+	l := newLoginDefs()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return l
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		key, value := fields[0], fields[1]
+		switch key {
+		case loginDefsEnvPath:
+			l.envPath = strings.TrimPrefix(value, envPath+"=")
+		case loginDefsEnvSuPath:
+			l.envSuPath = strings.TrimPrefix(value, envPath+"=")
+		case loginDefsUmask:
+			if v, err := strconv.ParseInt(value, 8, 32); err == nil {
+				l.umask = int(v)
+			}
+		case loginDefsFailDelay:
+			l.failDelay, _ = strconv.Atoi(value)
+		case loginDefsLoginRetries:
+			if v, err := strconv.Atoi(value); err == nil && v > 0 {
+				l.loginRetries = v
+			}
+		case loginDefsLoginTimeout:
+			l.loginTimeout, _ = strconv.Atoi(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		logPrint(err)
+	}
+	return l
+}
+
+// Gets PATH defined for user with uid, root uses ENV_SUPATH if available.
+func (l *loginDefs) getPath(uid int) string {
+	if l == nil {
+		return ""
+	}
+	if uid == 0 && l.envSuPath != "" {
+		return l.envSuPath
+	}
+	return l.envPath
+}
+
+// Gets umask, -1 means undefined.
+func (l *loginDefs) getUmask() int {
+	if l == nil {
+		return -1
+	}
+	return l.umask
+}
+
+// Gets delay in seconds after failed login.
+func (l *loginDefs) getFailDelay() int {
+	if l == nil {
+		return 0
+	}
+	return l.failDelay
+}
+
+// Gets number of allowed login attempts.
+func (l *loginDefs) getLoginRetries() int {
+	if l == nil || l.loginRetries <= 0 {
+		return 1
+	}
+	return l.loginRetries
+}
+
+// Gets timeout of login in seconds, 0 means no timeout.
+func (l *loginDefs) getLoginTimeout() int {
+	if l == nil {
+		return 0
+	}
+	return l.loginTimeout
+}
+
+// Gets PATH for user's session, value from login.defs has priority over PATH of emptty.
+func getSessionPath(conf *config, usr *sysuser) string {
+	if path := conf.Defs.getPath(usr.uid); path != "" {
+		return path
+	}
+	if path := os.Getenv(envPath); path != "" && isEnvKeyPassable(conf, envPath) {
+		return path
+	}
+	return getDefaultSessionPath(usr.uid)
+}
+
+// Gets effective PATH of user's session, that is used to look up session commands before session is started.
+// PATH from login.defs, environment files and ~/.local/bin are included, login shell is not started.
+func getUserSessionPath(conf *config, usr *sysuser) string {
+	if usr.lookPath != "" {
+		return usr.lookPath
+	}
+
+	envUsr := &sysuser{username: usr.username, homedir: usr.homedir, uid: usr.uid, gid: usr.gid, gids: usr.gids, gidsu32: usr.gidsu32, env: make(map[string]string)}
+	for key, value := range usr.env {
+		envUsr.env[key] = value
+	}
+	envUsr.setenvIfEmpty(envHome, usr.homedir)
+	envUsr.setenvIfEmpty(envPath, getSessionPath(conf, usr))
+	defineEnvironmentFromFiles(envUsr, []string{pathSystemEnvironment, pathEmpttyEnvironment}, getUserEnvironmentDir(envUsr))
+
+	paths := filepath.SplitList(envUsr.getenv(envPath))
+	if localBin := filepath.Join(usr.homedir, pathLocalBin); !contains(paths, localBin) {
+		paths = append(paths, localBin)
+	}
+	usr.lookPath = strings.Join(paths, string(filepath.ListSeparator))
+	return usr.lookPath
+}
+
+// Starts command with umask defined in login.defs, if available. Umask of emptty is restored right after the start,
+// so only started process inherits it.
+func startWithUmask(conf *config, cmd *exec.Cmd) error {
+	umask := conf.Defs.getUmask()
+	if umask < 0 {
+		return cmd.Start()
+	}
+
+	origUmask := syscall.Umask(umask)
+	err := cmd.Start()
+	syscall.Umask(origUmask)
+	return err
+}
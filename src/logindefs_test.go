@@ -0,0 +1,91 @@
+package src
+
+import (
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestReadLoginDefs(t *testing.T) {
+	l := readLoginDefs(getTestingPath("login.defs"))
+
+	if l.getPath(1000) != "/usr/local/bin:/usr/bin:/bin" {
+		t.Errorf("TestReadLoginDefs: unexpected ENV_PATH '%s'", l.getPath(1000))
+	}
+
+	if l.getPath(0) != "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin" {
+		t.Errorf("TestReadLoginDefs: unexpected ENV_SUPATH '%s'", l.getPath(0))
+	}
+
+	if l.getUmask() != 027 {
+		t.Errorf("TestReadLoginDefs: unexpected UMASK '%o'", l.getUmask())
+	}
+
+	if l.getFailDelay() != 3 {
+		t.Errorf("TestReadLoginDefs: unexpected FAIL_DELAY '%d'", l.getFailDelay())
+	}
+
+	if l.getLoginRetries() != 5 {
+		t.Errorf("TestReadLoginDefs: unexpected LOGIN_RETRIES '%d'", l.getLoginRetries())
+	}
+
+	if l.getLoginTimeout() != 60 {
+		t.Errorf("TestReadLoginDefs: unexpected LOGIN_TIMEOUT '%d'", l.getLoginTimeout())
+	}
+}
+
+func TestReadLoginDefsDefaults(t *testing.T) {
+	for _, l := range []*loginDefs{readLoginDefs(getTestingPath("non-existing-login.defs")), nil} {
+		if l.getPath(0) != "" || l.getPath(1000) != "" {
+			t.Error("TestReadLoginDefsDefaults: no PATH was expected")
+		}
+
+		if l.getUmask() != -1 {
+			t.Error("TestReadLoginDefsDefaults: no UMASK was expected")
+		}
+
+		if l.getFailDelay() != 0 || l.getLoginTimeout() != 0 {
+			t.Error("TestReadLoginDefsDefaults: no FAIL_DELAY or LOGIN_TIMEOUT was expected")
+		}
+
+		if l.getLoginRetries() != 1 {
+			t.Error("TestReadLoginDefsDefaults: single login attempt was expected")
+		}
+	}
+}
+
+func TestGetSessionPath(t *testing.T) {
+	t.Setenv(envPath, "/emptty/bin")
+
+	conf := &config{}
+	if path := getSessionPath(conf, &sysuser{uid: 1000}); path != "/emptty/bin" {
+		t.Errorf("TestGetSessionPath: PATH of emptty was expected, but was '%s'", path)
+	}
+
+	conf.Defs = readLoginDefs(getTestingPath("login.defs"))
+	if path := getSessionPath(conf, &sysuser{uid: 1000}); path != "/usr/local/bin:/usr/bin:/bin" {
+		t.Errorf("TestGetSessionPath: PATH from login.defs was expected, but was '%s'", path)
+	}
+}
+
+func TestStartWithUmask(t *testing.T) {
+	origUmask := syscall.Umask(022)
+	defer syscall.Umask(origUmask)
+
+	var sb strings.Builder
+	cmd := exec.Command("/bin/sh", "-c", "umask")
+	cmd.Stdout = &sb
+	if err := startWithUmask(&config{Defs: &loginDefs{umask: 077}}, cmd); err != nil {
+		t.Fatal("TestStartWithUmask: command was not started")
+	}
+	cmd.Wait()
+
+	if strings.TrimSpace(sb.String()) != "0077" {
+		t.Errorf("TestStartWithUmask: unexpected umask of command '%s'", strings.TrimSpace(sb.String()))
+	}
+
+	if umask := syscall.Umask(022); umask != 022 {
+		t.Errorf("TestStartWithUmask: umask of emptty was changed to '%o'", umask)
+	}
+}
@@ -0,0 +1,207 @@
+package src
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	strReverseOn  = "\x1b[7m"
+	strReverseOff = "\x1b[27m"
+	strHideCursor = "\x1b[?25l"
+	strShowCursor = "\x1b[?25h"
+
+	menuPageSize = 10
+)
+
+type enKey byte
+
+const (
+	keyOther enKey = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyEnter
+	keyCommand
+)
+
+var regexAnsiSequence = regexp.MustCompile("\x1b\\[[0-9;?]*[A-Za-z]")
+
+// stdinReader is shared by interactive menu and selection prompt, so keys typed ahead are not lost between them.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// Checks, if interactive selection is enabled and could be used on current terminal.
+func isInteractiveSelection(conf *config) bool {
+	return conf.InteractiveSelect && os.Getenv("TERM") != "dumb" && isTerminal(os.Stdin.Fd()) && isTerminal(os.Stdout.Fd())
+}
+
+// Allows to select desktop with arrow keys. Returns -1, if command prompt was requested.
+func selectDesktopInteractively(conf *config, desktops []*desktop, selected int) (int, error) {
+	fd := os.Stdin.Fd()
+	termios, err := setTerminalRaw(fd)
+	if err != nil {
+		return -1, err
+	}
+	defer restoreTerminal(fd, termios)
+
+	fmt.Print(strHideCursor)
+	defer fmt.Print(strShowCursor)
+
+	rows := 0
+	for {
+		menu := renderMenu(conf, desktops, selected)
+		if rows > 0 {
+			fmt.Printf("\r\x1b[%dA", rows)
+		}
+		fmt.Print("\r\x1b[J" + menu)
+		rows = countTerminalRows(menu, getTerminalWidth(os.Stdout.Fd()))
+
+		key, err := readKey(stdinReader)
+		if err != nil {
+			return -1, err
+		}
+		switch key {
+		case keyEnter:
+			fmt.Println()
+			return selected, nil
+		case keyCommand:
+			if conf.AllowCommands {
+				fmt.Println()
+				return -1, nil
+			}
+		default:
+			selected = moveSelection(key, selected, len(desktops))
+		}
+	}
+}
+
+// Renders menu with highlighted selected desktop.
+func renderMenu(conf *config, desktops []*desktop, selected int) string {
+	var sb strings.Builder
+	sb.WriteString("\n")
+	fprintDesktops(&sb, conf, desktops, selected)
+	fmt.Fprintf(&sb, "\n\n%sSelect [%d]: ", conf.GetIndentString(), selected)
+	return sb.String()
+}
+
+// Moves selection according to pressed key and keeps it in range of desktops.
+func moveSelection(key enKey, selected, count int) int {
+	switch key {
+	case keyUp, keyLeft:
+		selected--
+	case keyDown, keyRight:
+		selected++
+	case keyPageUp:
+		selected -= menuPageSize
+	case keyPageDown:
+		selected += menuPageSize
+	case keyHome:
+		selected = 0
+	case keyEnd:
+		selected = count - 1
+	}
+
+	if selected >= count {
+		selected = count - 1
+	}
+	if selected < 0 {
+		selected = 0
+	}
+	return selected
+}
+
+// Reads single key from terminal input, escape sequences of arrows and navigation keys are recognized.
+func readKey(r *bufio.Reader) (enKey, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return keyOther, err
+	}
+
+	switch b {
+	case '\r', '\n':
+		return keyEnter, nil
+	case ':':
+		return keyCommand, nil
+	case 0x1b:
+		if r.Buffered() == 0 {
+			return keyOther, nil
+		}
+	default:
+		return keyOther, nil
+	}
+
+	if b, err = r.ReadByte(); err != nil || (b != '[' && b != 'O') {
+		return keyOther, err
+	}
+	if b, err = r.ReadByte(); err != nil {
+		return keyOther, err
+	}
+
+	switch b {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	case 'H':
+		return keyHome, nil
+	case 'F':
+		return keyEnd, nil
+	}
+
+	if b < '0' || b > '9' {
+		return keyOther, nil
+	}
+	code := string(b)
+	for {
+		next, err := r.ReadByte()
+		if err != nil {
+			return keyOther, err
+		}
+		if next == '~' {
+			break
+		}
+		if next < '0' || next > '9' {
+			return keyOther, nil
+		}
+		code += string(next)
+	}
+
+	switch code {
+	case "1", "7":
+		return keyHome, nil
+	case "4", "8":
+		return keyEnd, nil
+	case "5":
+		return keyPageUp, nil
+	case "6":
+		return keyPageDown, nil
+	}
+	return keyOther, nil
+}
+
+// Counts rows the cursor moved down by printing text, wrapping of long lines is included, if width is known.
+func countTerminalRows(text string, width int) int {
+	lines := strings.Split(regexAnsiSequence.ReplaceAllString(text, ""), "\n")
+	rows := len(lines) - 1
+	if width > 0 {
+		for _, line := range lines {
+			if length := utf8.RuneCountInString(line); length > 0 {
+				rows += (length - 1) / width
+			}
+		}
+	}
+	return rows
+}
@@ -0,0 +1,66 @@
+package src
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestReadKey(t *testing.T) {
+	input := "\x1b[A\x1b[B\x1bOC\x1b[D\x1b[5~\x1b[6~\x1b[H\x1b[4~\r:x\x1b[15~"
+	expected := []enKey{keyUp, keyDown, keyRight, keyLeft, keyPageUp, keyPageDown, keyHome, keyEnd, keyEnter, keyCommand, keyOther, keyOther}
+
+	r := bufio.NewReader(strings.NewReader(input))
+	for i, e := range expected {
+		key, err := readKey(r)
+		if err != nil || key != e {
+			t.Errorf("TestReadKey: unexpected key %d on position %d, expected %d", key, i, e)
+		}
+	}
+
+	if _, err := readKey(r); err == nil {
+		t.Error("TestReadKey: error expected at the end of input")
+	}
+}
+
+func TestMoveSelection(t *testing.T) {
+	if moveSelection(keyUp, 0, 5) != 0 || moveSelection(keyDown, 4, 5) != 4 {
+		t.Error("TestMoveSelection: selection should stay in range")
+	}
+
+	if moveSelection(keyDown, 1, 5) != 2 || moveSelection(keyLeft, 1, 5) != 0 {
+		t.Error("TestMoveSelection: selection should be moved by one")
+	}
+
+	if moveSelection(keyPageDown, 1, 15) != 11 || moveSelection(keyPageUp, 5, 15) != 0 {
+		t.Error("TestMoveSelection: selection should be moved by page")
+	}
+
+	if moveSelection(keyEnd, 1, 15) != 14 || moveSelection(keyHome, 5, 15) != 0 {
+		t.Error("TestMoveSelection: selection should be moved to the edge")
+	}
+}
+
+func TestRenderMenu(t *testing.T) {
+	desktops := []*desktop{{name: "a", envOrigin: Xorg}, {name: "b", envOrigin: Wayland}, {name: "c", envOrigin: Wayland, unavailable: true}}
+	conf := &config{VerticalSelection: true, IdentifyEnvs: true, IndentSelection: 2}
+
+	menu := renderMenu(conf, desktops, 1)
+	if menu != "\n  |Xorg|\n  [0] a\n  \n  |Wayland|\n  "+strReverseOn+"[1] b"+strReverseOff+"\n  [2] c (unavailable)\n\n  Select [1]: " {
+		t.Errorf("TestRenderMenu: unexpected menu %q", menu)
+	}
+
+	if countTerminalRows(menu, 0) != 8 {
+		t.Error("TestRenderMenu: unexpected count of rows")
+	}
+}
+
+func TestCountTerminalRows(t *testing.T) {
+	if countTerminalRows("abc", 80) != 0 || countTerminalRows("\nabc\n", 80) != 2 {
+		t.Error("TestCountTerminalRows: unexpected count of rows without wrapping")
+	}
+
+	if countTerminalRows("\n"+strings.Repeat("x", 25)+"\x1b[7m\x1b[27m", 10) != 3 {
+		t.Error("TestCountTerminalRows: wrapped line and escape sequences should be counted")
+	}
+}
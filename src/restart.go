@@ -0,0 +1,136 @@
+package src
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	constSessionRestartNever     = "never"
+	constSessionRestartOnFailure = "on-failure"
+	constSessionRestartAlways    = "always"
+
+	maxSessionRestartDelay   = 60
+	sessionRestartResetAfter = 60 * time.Second
+
+	envDbusPrefix = "DBUS_"
+)
+
+// Environmental variables, that are defined for single run of session and are cleared before its restart.
+var sessionRunEnvKeys = []string{envDisplay, envWaylandDisplay, envXauthority, envXdgSessionType, envXdgSessDesktop, envXdgCurrDesktop, envDesktopSession}
+
+type enSessionRestart byte
+
+const (
+
+	// Never restart ended session
+	SessionRestartNever enSessionRestart = iota
+
+	// Restart session, only if it ended with error
+	SessionRestartOnFailure
+
+	// Restart session after any end
+	SessionRestartAlways
+)
+
+// Parses session restart config option.
+func parseSessionRestart(value, defaultValue string) enSessionRestart {
+	switch strings.ToLower(sanitizeValue(value, defaultValue)) {
+	case constSessionRestartOnFailure:
+		return SessionRestartOnFailure
+	case constSessionRestartAlways:
+		return SessionRestartAlways
+	}
+	return SessionRestartNever
+}
+
+// Stringify enSessionRestart value.
+func (r enSessionRestart) stringify() string {
+	return []string{constSessionRestartNever, constSessionRestartOnFailure, constSessionRestartAlways}[int(r)]
+}
+
+// Checks, if session should be restarted according to SESSION_RESTART and error of ended session.
+func shouldRestartSession(conf *config, err error) bool {
+	switch conf.SessionRestart {
+	case SessionRestartAlways:
+		return true
+	case SessionRestartOnFailure:
+		return err != nil
+	}
+	return false
+}
+
+// Gets delay before next restart, SESSION_RESTART_DELAY is doubled with each restart up to 60 seconds.
+func getSessionRestartDelay(conf *config, restarts int) time.Duration {
+	delay := conf.SessionRestartDelay
+	for i := 0; i < restarts && delay < maxSessionRestartDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxSessionRestartDelay {
+		delay = maxSessionRestartDelay
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return time.Duration(delay) * time.Second
+}
+
+// Checks, if SESSION_RESTART_LIMIT was reached by restarts in a row, limit 0 means unlimited restarts.
+func isSessionRestartLimitReached(conf *config, restarts int) bool {
+	return conf.SessionRestartLimit > 0 && restarts >= conf.SessionRestartLimit
+}
+
+// Clears environmental variables of ended session run, so restarted session does not connect to its dbus or display.
+func clearSessionRunEnviron(usr *sysuser) {
+	for key := range usr.env {
+		if strings.HasPrefix(key, envDbusPrefix) || contains(sessionRunEnvKeys, key) {
+			usr.unsetenv(key)
+		}
+	}
+}
+
+// Checks, if error of ended session should be shown. Errors of console session are only logged, unless restart
+// limit or time limit was reached.
+func isSessionErrShown(conf *config, d *desktop, err error) bool {
+	return err != nil && (d.env != Console || conf.SessionRestart != SessionRestartNever || isTimeLimitErr(err))
+}
+
+// Runs session and restarts it in the same authenticated session according to SESSION_RESTART.
+// If SESSION_RESTART_LIMIT (0 means unlimited) or time limit is reached, error is returned. Restarts are counted
+// only in a row, counter and delay are reset, if session was running for at least 60 seconds.
+func runSession(h *sessionHandle, d *desktop, conf *config) error {
+	restarts := 0
+	for {
+		h.session = createSession(h.auth, d, conf)
+		started := time.Now()
+		err := h.session.start()
+		if _, limitErr := getTimeLimitRemaining(h.auth.usr().username, time.Now()); limitErr != nil {
+			logPrint("Session ended by time limit: ", limitErr)
+			return limitErr
+		}
+		if h.interrupted || h.session.interrupted || !shouldRestartSession(conf, err) {
+			return err
+		}
+
+		if time.Since(started) >= sessionRestartResetAfter {
+			restarts = 0
+		}
+		if isSessionRestartLimitReached(conf, restarts) {
+			logPrintf("Session restart limit %d was reached", conf.SessionRestartLimit)
+			clearScreen(nil)
+			return fmt.Errorf("session ended %d times in a row, restart limit was reached, please check logs", restarts+1)
+		}
+
+		delay := getSessionRestartDelay(conf, restarts)
+		restarts++
+		if conf.SessionRestartLimit > 0 {
+			logPrintf("Session ended, restarting in %s (%d/%d)", delay, restarts, conf.SessionRestartLimit)
+		} else {
+			logPrintf("Session ended, restarting in %s (%d)", delay, restarts)
+		}
+		fmt.Printf("\n%sSession ended, restarting in %s\n", conf.GetIndentString(), delay)
+		clearSessionRunEnviron(h.auth.usr())
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,82 @@
+package src
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseSessionRestart(t *testing.T) {
+	for value, expected := range map[string]enSessionRestart{"": SessionRestartNever, "On-Failure": SessionRestartOnFailure, "always": SessionRestartAlways, "unknown": SessionRestartNever} {
+		if result := parseSessionRestart(value, "never"); result != expected {
+			t.Errorf("TestParseSessionRestart: unexpected result for '%s'", value)
+		}
+	}
+
+	if SessionRestartOnFailure.stringify() != "on-failure" {
+		t.Error("TestParseSessionRestart: unexpected stringify result")
+	}
+}
+
+func TestShouldRestartSession(t *testing.T) {
+	err := errors.New("crashed")
+
+	conf := &config{SessionRestart: SessionRestartNever}
+	if shouldRestartSession(conf, err) || shouldRestartSession(conf, nil) {
+		t.Error("TestShouldRestartSession: session should never be restarted")
+	}
+
+	conf.SessionRestart = SessionRestartOnFailure
+	if !shouldRestartSession(conf, err) || shouldRestartSession(conf, nil) {
+		t.Error("TestShouldRestartSession: session should be restarted only on failure")
+	}
+
+	conf.SessionRestart = SessionRestartAlways
+	if !shouldRestartSession(conf, err) || !shouldRestartSession(conf, nil) {
+		t.Error("TestShouldRestartSession: session should be always restarted")
+	}
+}
+
+func TestGetSessionRestartDelay(t *testing.T) {
+	conf := &config{SessionRestartDelay: 2}
+	for restarts, expected := range []int{2, 4, 8, 16, 32, 60, 60} {
+		if delay := getSessionRestartDelay(conf, restarts); delay != time.Duration(expected)*time.Second {
+			t.Errorf("TestGetSessionRestartDelay: unexpected delay %s for %d restarts", delay, restarts)
+		}
+	}
+
+	conf.SessionRestartDelay = 0
+	if delay := getSessionRestartDelay(conf, 5); delay != 0 {
+		t.Errorf("TestGetSessionRestartDelay: unexpected delay %s", delay)
+	}
+}
+
+func TestIsSessionRestartLimitReached(t *testing.T) {
+	conf := &config{SessionRestartLimit: 3}
+	if isSessionRestartLimitReached(conf, 2) || !isSessionRestartLimitReached(conf, 3) {
+		t.Error("TestIsSessionRestartLimitReached: unexpected result for limit 3")
+	}
+
+	conf.SessionRestartLimit = 0
+	if isSessionRestartLimitReached(conf, 0) || isSessionRestartLimitReached(conf, 100) {
+		t.Error("TestIsSessionRestartLimitReached: limit 0 should mean unlimited restarts")
+	}
+}
+
+func TestClearSessionRunEnviron(t *testing.T) {
+	usr := &sysuser{env: map[string]string{
+		dbusSessionBusAddress: "unix:path=/tmp/dbus-dead",
+		dbusSessionBusPid:     "1234",
+		envDisplay:            ":1",
+		envWaylandDisplay:     "wayland-1",
+		envXdgSessionType:     "x11",
+		envXdgCurrDesktop:     "i3",
+		envXdgSessionId:       "3",
+		envHome:               "/home/user",
+	}}
+
+	clearSessionRunEnviron(usr)
+	if len(usr.env) != 2 || usr.getenv(envXdgSessionId) != "3" || usr.getenv(envHome) != "/home/user" {
+		t.Errorf("TestClearSessionRunEnviron: unexpected environment %v", usr.env)
+	}
+}
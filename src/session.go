@@ -1,9 +1,11 @@
 package src
 
 import (
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -12,6 +14,8 @@ import (
 
 const (
 	envXdgConfigHome   = "XDG_CONFIG_HOME"
+	envXdgDataHome     = "XDG_DATA_HOME"
+	envXdgDataDirs     = "XDG_DATA_DIRS"
 	envXdgRuntimeDir   = "XDG_RUNTIME_DIR"
 	envXdgSessionId    = "XDG_SESSION_ID"
 	envXdgSessionType  = "XDG_SESSION_TYPE"
@@ -29,11 +33,15 @@ const (
 	envDesktopSession  = "DESKTOP_SESSION"
 	envXdgSessDesktop  = "XDG_SESSION_DESKTOP"
 	envUid             = "UID"
+	envTerm            = "TERM"
 	envXdgCurrDesktop  = "XDG_CURRENT_DESKTOP"
+	envWaylandDisplay  = "WAYLAND_DISPLAY"
 
 	userExitScript    = ".config/emptty-exit"
 	exitScriptKey     = "TIMEOUT"
 	exitScriptTimeout = 3
+
+	sessionHookTimeout = 10
 )
 
 // session defines basic functions expected from desktop session
@@ -63,13 +71,15 @@ func createSession(h authHandle, d *desktop, conf *config) *commonSession {
 		s.session = &waylandSession{s}
 	case Xorg:
 		s.session = &xorgSession{s, nil, nil}
+	case Console:
+		s.session = &consoleSession{s}
 	}
 
 	return s
 }
 
-// Performs common start of session
-func (s *commonSession) start() {
+// Performs common start of session, returns error if session or its carrier finished with error.
+func (s *commonSession) start() error {
 	s.defineEnvironment()
 	applyRlimits()
 
@@ -83,14 +93,18 @@ func (s *commonSession) start() {
 		s.dbus = &dbus{}
 	}
 
+	s.runSessionHooks(func(d *desktop) []string { return d.preExec })
+
 	session, strExec := s.prepareGuiCommand()
 	s.cmd = session
 
-	if sessionErrLog, sessionErrLogErr := initSessionErrorLogger(s.conf); sessionErrLogErr == nil {
-		session.Stderr = sessionErrLog
-		defer sessionErrLog.Close()
-	} else {
-		logPrint(sessionErrLogErr)
+	if session.Stderr == nil {
+		if sessionErrLog, sessionErrLogErr := initSessionErrorLogger(s.conf); sessionErrLogErr == nil {
+			session.Stderr = sessionErrLog
+			defer sessionErrLog.Close()
+		} else {
+			logPrint(sessionErrLogErr)
+		}
 	}
 
 	if s.dbus != nil {
@@ -104,7 +118,7 @@ func (s *commonSession) start() {
 	logPrint("Starting " + strExec)
 	session.Env = s.auth.usr().environ()
 
-	if err := session.Start(); err != nil {
+	if err := startWithUmask(s.conf, session); err != nil {
 		s.finishCarrier()
 		handleErr(err)
 	}
@@ -117,12 +131,16 @@ func (s *commonSession) start() {
 	utmpEntry := addUtmpEntry(s.auth.usr().username, pid, s.conf.strTTY(), s.auth.usr().getenv(envDisplay))
 	logPrint("Added utmp entry")
 
+	stopTimeLimit := s.startTimeLimit()
 	err := session.Wait()
+	stopTimeLimit()
 
 	if s.dbus != nil && s.dbus.pid > 0 {
 		s.dbus.interrupt()
 	}
 
+	s.runSessionHooks(func(d *desktop) []string { return d.postExec })
+
 	carrierErr := s.finishCarrier()
 
 	s.runExitScript()
@@ -131,13 +149,62 @@ func (s *commonSession) start() {
 	logPrint("Ended utmp entry")
 
 	if !s.interrupted && err != nil {
-		logPrint(strExec + " finished with error: " + err.Error() + ". For more details see `SESSION_ERROR_LOGGING` in configuration.")
-		handleStrErr(s.d.env.string() + " session finished with error, please check logs")
+		if s.d.env == Console {
+			logPrint(strExec + " finished with error: " + err.Error())
+		} else {
+			logPrint(strExec + " finished with error: " + err.Error() + ". For more details see `SESSION_ERROR_LOGGING` in configuration.")
+		}
+		return errors.New(s.d.env.string() + " session finished with error, please check logs")
 	}
 
 	if !s.interrupted && carrierErr != nil {
 		logPrint(s.d.env.string() + " finished with error: " + carrierErr.Error())
-		handleStrErr(s.d.env.string() + " finished with error, please check logs")
+		return errors.New(s.d.env.string() + " finished with error, please check logs")
+	}
+	return nil
+}
+
+// Defines environment variables declared by selected desktop and user configuration.
+func (s *commonSession) defineDesktopEnvironment() {
+	for _, d := range []*desktop{s.d.child, s.d} {
+		if d == nil || len(d.envVars) == 0 {
+			continue
+		}
+
+		keys := make([]string, 0, len(d.envVars))
+		for key := range d.envVars {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if contains(protectedEnvKeys, key) {
+				logPrintf("Session %s could not override %s", d.name, key)
+				continue
+			}
+			s.auth.usr().setenv(key, expandEnvValue(d.envVars[key], s.auth.usr()))
+		}
+	}
+}
+
+// Runs hooks of selected desktop and user configuration as user.
+func (s *commonSession) runSessionHooks(getHook func(d *desktop) []string) {
+	for _, d := range []*desktop{s.d.child, s.d} {
+		if d == nil || len(getHook(d)) == 0 {
+			continue
+		}
+
+		args := getHook(d)
+		logPrint("Running session hook " + strings.Join(args, " "))
+		cmd := cmdArgsAsUser(s.auth.usr(), args)
+		cmd.Dir = s.auth.usr().homedir
+		if err := cmd.Start(); err != nil {
+			logPrint("error during start of session hook ", err)
+			continue
+		}
+		if err := waitWithTimeout(cmd, sessionHookTimeout); err != nil {
+			logPrint("session hook finished with error ", err)
+		}
 	}
 }
 
@@ -157,6 +224,7 @@ func (s *commonSession) mkXdgRuntimeDir() {
 // Prepares environment and env variables for authorized user.
 func (s *commonSession) defineEnvironment() {
 	s.auth.defineSpecificEnvVariables()
+	passAllowedEnviron(s.conf, s.auth.usr())
 
 	s.auth.usr().setenv(envHome, s.auth.usr().homedir)
 	s.auth.usr().setenv(envPwd, s.auth.usr().homedir)
@@ -174,7 +242,7 @@ func (s *commonSession) defineEnvironment() {
 	if s.conf.UserLang != "" {
 		s.auth.usr().setenv(envLang, s.conf.UserLang)
 	}
-	s.auth.usr().setenvIfEmpty(envPath, os.Getenv(envPath))
+	s.auth.usr().setenvIfEmpty(envPath, getSessionPath(s.conf, s.auth.usr()))
 
 	if !s.conf.NoXdgFallback {
 		if s.d.name != "" {
@@ -192,10 +260,16 @@ func (s *commonSession) defineEnvironment() {
 		}
 	}
 
+	defineEnvironmentFromFiles(s.auth.usr(), []string{pathSystemEnvironment, pathEmpttyEnvironment}, getUserEnvironmentDir(s.auth.usr()))
+	if s.conf.ImportShellEnv {
+		importShellEnviron(s.auth.usr(), s.auth.usr().getShell(), s.conf.ImportShellEnvTime)
+	}
+	s.defineDesktopEnvironment()
+
 	logPrint("Defined Environment")
 
 	// create XDG folder
-	if !s.conf.NoXdgFallback {
+	if !s.conf.NoXdgFallback && !s.conf.DryRun {
 		if !fileExists(s.auth.usr().getenv(envXdgRuntimeDir)) {
 			s.mkXdgRuntimeDir()
 
@@ -210,26 +284,52 @@ func (s *commonSession) defineEnvironment() {
 
 // Prepares command for starting GUI.
 func (s *commonSession) prepareGuiCommand() (cmd *exec.Cmd, strExec string) {
+	if s.d.env == Console {
+		return s.prepareConsoleCommand()
+	}
+
 	strExec, allowStartupPrefix := s.d.getStrExec()
+	args := s.d.getExecArgs()
 
+	allowStartupPrefix = allowStartupPrefix && !s.d.failsafe
 	startScript := s.d.isUser && !allowStartupPrefix
 
 	if allowStartupPrefix && s.conf.XinitrcLaunch && s.d.env == Xorg && !strings.Contains(strExec, ".xinitrc") && fileExists(s.auth.usr().homedir+"/.xinitrc") {
 		startScript = true
 		strExec = s.auth.usr().homedir + "/.xinitrc " + strExec
+		args = append([]string{s.auth.usr().homedir + "/.xinitrc"}, args...)
 	} else if allowStartupPrefix && s.conf.DbusLaunch && !strings.Contains(strExec, "dbus-launch") {
 		s.dbus = &dbus{}
 	}
 
 	if startScript {
-		cmd = cmdAsUser(s.auth.usr(), s.getLoginShell()+" "+strExec)
+		args = append(parseExec(s.getLoginShell()), args...)
 	} else {
-		cmd = cmdAsUser(s.auth.usr(), strExec)
+		args = s.getProfileArgs(strExec, args)
 	}
+	cmd = cmdArgsAsUser(s.auth.usr(), args)
+	// Session leads its own process group, so it could be ended with all its processes
+	cmd.SysProcAttr.Setpgid = true
 
 	return cmd, strExec
 }
 
+// Sets TTY ownership to defined uid, but keeps the original gid.
+func (s *commonSession) setTTYOwnership(conf *config, uid int) error {
+	info, err := os.Stat(conf.ttyPath())
+	if err != nil {
+		return err
+	}
+	stat := info.Sys().(*syscall.Stat_t)
+
+	err = os.Chown(conf.ttyPath(), uid, int(stat.Gid))
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(conf.ttyPath(), 0620)
+	return err
+}
+
 // Gets preferred login shell
 func (s *commonSession) getLoginShell() string {
 	if s.d.loginShell != "" {
@@ -238,6 +338,14 @@ func (s *commonSession) getLoginShell() string {
 	return "/bin/sh"
 }
 
+// Gets arguments to start command of profile through its login shell, if it is defined. Otherwise args are returned.
+func (s *commonSession) getProfileArgs(strExec string, args []string) []string {
+	if !s.d.isProfile() || s.d.loginShell == "" {
+		return args
+	}
+	return append(parseExec(s.d.loginShell), "-c", "exec "+strExec)
+}
+
 // Runs session exit script
 func (s *commonSession) runExitScript() {
 	filePath := filepath.Join(s.auth.usr().homedir, userExitScript)
@@ -255,24 +363,29 @@ func (s *commonSession) runExitScript() {
 			return
 		}
 
-		c := make(chan error)
 		cmd := cmdAsUser(s.auth.usr(), s.getLoginShell(), filePath)
 		if err := cmd.Start(); err != nil {
 			logPrint("error during start of exit script", err)
 			return
 		}
-		go func(c chan error) {
-			c <- cmd.Wait()
-		}(c)
-
-		select {
-		case <-time.After(time.Duration(timeout) * time.Second):
-			syscall.Kill(cmd.Process.Pid, syscall.SIGKILL)
-		case err := <-c:
-			if err != nil {
-				logPrint(err)
-			}
+		if err := waitWithTimeout(cmd, timeout); err != nil {
+			logPrint(err)
 		}
-		close(c)
+	}
+}
+
+// Waits for started command, if it does not finish in timeout (in seconds), it is killed.
+func waitWithTimeout(cmd *exec.Cmd, timeout int) error {
+	c := make(chan error, 1)
+	go func() {
+		c <- cmd.Wait()
+	}()
+
+	select {
+	case <-time.After(time.Duration(timeout) * time.Second):
+		syscall.Kill(cmd.Process.Pid, syscall.SIGKILL)
+		return errors.New("killed after timeout")
+	case err := <-c:
+		return err
 	}
 }
@@ -0,0 +1,80 @@
+package src
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+const defaultConsoleTerm = "linux"
+
+// consoleSession defines structure for plain console session started directly on TTY
+type consoleSession struct {
+	*commonSession
+}
+
+// Passes TTY ownership to user and releases controlling terminal, console session has no carrier
+func (c *consoleSession) startCarrier() {
+	if err := c.setTTYOwnership(c.conf, c.auth.usr().uid); err != nil {
+		logPrint(err)
+	}
+
+	// Hangup is sent to foreground process group, when emptty releases its controlling terminal
+	ignoreHangup(func() {
+		if err := releaseControllingTerminal(os.Stdin.Fd()); err != nil {
+			logPrint("Could not release controlling terminal: ", err)
+		}
+	})
+}
+
+// Gets -1 as carrier Pid
+func (c *consoleSession) getCarrierPid() int {
+	return -1
+}
+
+// Reverts TTY ownership
+func (c *consoleSession) finishCarrier() error {
+	return c.setTTYOwnership(c.conf, os.Getuid())
+}
+
+// Prepares command for console session. If no command is defined, user's login shell is started.
+func (s *commonSession) prepareConsoleCommand() (cmd *exec.Cmd, strExec string) {
+	usr := s.auth.usr()
+
+	if s.d.exec == "" && s.d.path == "" && s.d.child == nil {
+		strExec = usr.getShell()
+		cmd = cmdArgsAsUser(usr, []string{strExec})
+		cmd.Args[0] = "-" + filepath.Base(strExec)
+	} else {
+		var allowStartupPrefix bool
+		strExec, allowStartupPrefix = s.d.getStrExec()
+		args := s.d.getExecArgs()
+		if s.d.isUser && !allowStartupPrefix {
+			args = append(parseExec(s.getLoginShell()), args...)
+		} else {
+			args = s.getProfileArgs(strExec, args)
+		}
+		cmd = cmdArgsAsUser(usr, args)
+	}
+
+	term := os.Getenv(envTerm)
+	if term == "" || term == "dumb" {
+		term = defaultConsoleTerm
+	}
+	usr.setenvIfEmpty(envTerm, term)
+
+	cmd.Dir = usr.homedir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if !hasTerminalSession(os.Stdin.Fd()) {
+		cmd.SysProcAttr.Setsid = true
+		cmd.SysProcAttr.Setctty = true
+		cmd.SysProcAttr.Ctty = 0
+	} else {
+		logPrint("TTY is controlled by another session, console is started without own session")
+	}
+
+	return cmd, strExec
+}
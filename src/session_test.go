@@ -1,6 +1,9 @@
 package src
 
 import (
+	"os"
+	"os/user"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -116,3 +119,207 @@ func TestPrepareGuiCommandXinitrc(t *testing.T) {
 		t.Errorf("TestPrepareGuiCommandXinitrc: dbus-launch should be enabled: '%s'", exec)
 	}
 }
+
+func TestPrepareGuiCommandProfileLoginShell(t *testing.T) {
+	c := &config{}
+	u := &sysuser{uid: 3000, gid: 2000, homedir: "/dev/null", env: make(map[string]string)}
+	a := &testAuth{&authBase{}, u}
+	d := &desktop{id: constProfileIdPrefix + "work", exec: "/usr/bin/sway --unsupported-gpu", env: Wayland, envOrigin: UserCustom}
+
+	s := &commonSession{nil, a, d, c, nil, nil, false}
+
+	cmd, _ := s.prepareGuiCommand()
+	if strings.Join(cmd.Args, "|") != "/usr/bin/sway|--unsupported-gpu" {
+		t.Errorf("TestPrepareGuiCommandProfileLoginShell: profile without login shell should be started directly: '%s'", cmd.String())
+	}
+
+	d.loginShell = "/bin/bash --login"
+	cmd, _ = s.prepareGuiCommand()
+	if strings.Join(cmd.Args, "|") != "/bin/bash|--login|-c|exec /usr/bin/sway --unsupported-gpu" {
+		t.Errorf("TestPrepareGuiCommandProfileLoginShell: profile should be started through its login shell: '%s'", cmd.String())
+	}
+
+	d.env = Console
+	cmd, _ = s.prepareGuiCommand()
+	if strings.Join(cmd.Args, "|") != "/bin/bash|--login|-c|exec /usr/bin/sway --unsupported-gpu" {
+		t.Errorf("TestPrepareGuiCommandProfileLoginShell: console profile should be started through its login shell: '%s'", cmd.String())
+	}
+}
+
+func TestPrepareConsoleCommand(t *testing.T) {
+	t.Setenv(envTerm, "dumb")
+	c := &config{DbusLaunch: true}
+	u := &sysuser{uid: 3000, gid: 2000, homedir: getTestingPath("userHome3"), env: make(map[string]string)}
+	a := &testAuth{&authBase{}, u}
+	d := getConsoleDesktop(c)
+
+	s := &commonSession{nil, a, d, c, nil, nil, false}
+
+	cmd, exec := s.prepareGuiCommand()
+	if exec != "/bin/sh" || cmd.Path != "/bin/sh" || len(cmd.Args) != 1 || cmd.Args[0] != "-sh" {
+		t.Errorf("TestPrepareConsoleCommand: login shell was expected: '%s'", cmd.String())
+	}
+	if cmd.Stdin != os.Stdin || cmd.Stdout != os.Stdout || cmd.Stderr != os.Stderr || cmd.Dir != u.homedir {
+		t.Error("TestPrepareConsoleCommand: command should be attached to TTY")
+	}
+	if u.getenv(envTerm) != defaultConsoleTerm || s.dbus != nil {
+		t.Error("TestPrepareConsoleCommand: wrong TERM or dbus-launch should not be started")
+	}
+
+	c.ConsoleCommand = "tmux new-session -A"
+	s.d = getConsoleDesktop(c)
+	cmd, exec = s.prepareGuiCommand()
+	if exec != "tmux new-session -A" || strings.Join(cmd.Args, " ") != "tmux new-session -A" {
+		t.Errorf("TestPrepareConsoleCommand: console command was expected: '%s'", cmd.String())
+	}
+}
+
+func TestDefineDesktopEnvironment(t *testing.T) {
+	u := &sysuser{homedir: "/home/test", env: map[string]string{envHome: "/home/test"}}
+	a := &testAuth{&authBase{}, u}
+	child := &desktop{name: "child", envVars: map[string]string{"SESSION_VAR": "child", "CONFIG_DIR": "$HOME/.config/child"}}
+	d := &desktop{name: "user", child: child, envVars: map[string]string{"SESSION_VAR": "user", envHome: "/tmp"}}
+
+	s := &commonSession{nil, a, d, &config{}, nil, nil, false}
+
+	readOutput(func() {
+		s.defineDesktopEnvironment()
+	})
+
+	if u.getenv("SESSION_VAR") != "user" {
+		t.Errorf("TestDefineDesktopEnvironment: user configuration should override session, got '%s'", u.getenv("SESSION_VAR"))
+	}
+	if u.getenv("CONFIG_DIR") != "/home/test/.config/child" {
+		t.Errorf("TestDefineDesktopEnvironment: value was not expanded, got '%s'", u.getenv("CONFIG_DIR"))
+	}
+	if u.getenv(envHome) != "/home/test" {
+		t.Error("TestDefineDesktopEnvironment: protected variable was overridden")
+	}
+}
+
+func TestDefineEnvironmentProtectedPaths(t *testing.T) {
+	tmp := t.TempDir()
+	runtimeDir := filepath.Join(tmp, "run")
+	evilDir := filepath.Join(tmp, "evil")
+
+	envDir := filepath.Join(tmp, ".config", pathUserEnvironmentD)
+	os.MkdirAll(envDir, 0755)
+	content := envXdgRuntimeDir + "=" + evilDir + "/runtime\n" + envPwd + "=" + evilDir + "\n" + envXauthority + "=" + evilDir + "/xauth\n"
+	if err := os.WriteFile(filepath.Join(envDir, "x.conf"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	currentUser, _ := user.Current()
+	u := getSysuser(currentUser)
+	u.homedir = tmp
+	u.setenv(envXdgConfigHome, filepath.Join(tmp, ".config"))
+	u.setenv(envXdgRuntimeDir, runtimeDir)
+	a := &testAuth{&authBase{}, u}
+	d := &desktop{env: Wayland, envVars: map[string]string{envXdgRuntimeDir: evilDir + "/session"}}
+
+	s := &commonSession{nil, a, d, &config{}, nil, nil, false}
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	readOutput(func() {
+		s.defineEnvironment()
+	})
+
+	if u.getenv(envXdgRuntimeDir) != runtimeDir {
+		t.Errorf("TestDefineEnvironmentProtectedPaths: XDG_RUNTIME_DIR was redirected to '%s'", u.getenv(envXdgRuntimeDir))
+	}
+	if u.getenv(envPwd) != tmp {
+		t.Errorf("TestDefineEnvironmentProtectedPaths: PWD was redirected to '%s'", u.getenv(envPwd))
+	}
+	if u.getenv(envXauthority) != "" {
+		t.Errorf("TestDefineEnvironmentProtectedPaths: XAUTHORITY was redirected to '%s'", u.getenv(envXauthority))
+	}
+	if !fileExists(runtimeDir) {
+		t.Error("TestDefineEnvironmentProtectedPaths: runtime dir was not created")
+	}
+	if fileExists(evilDir) {
+		t.Error("TestDefineEnvironmentProtectedPaths: runtime dir was created in path defined by user")
+	}
+}
+
+func TestBuildXorgArgs(t *testing.T) {
+	c := &config{Tty: 7, XorgArgs: "-nolisten tcp", RootlessXorg: true, DaemonMode: true}
+	u := &sysuser{env: map[string]string{envDisplay: ":1"}}
+	a := &testAuth{&authBase{}, u}
+	d := &desktop{xorgArgs: []string{"-ardelay", "200"}}
+	x := &xorgSession{&commonSession{nil, a, d, c, nil, nil, false}, nil, nil}
+
+	args := strings.Join(x.buildXorgArgs("/usr/bin/Xorg"), " ")
+	if args != "vt7 :1 -keeptty -nolisten tcp -ardelay 200" {
+		t.Errorf("TestBuildXorgArgs: unexpected Xorg arguments '%s'", args)
+	}
+
+	d.child = getDesktop(getTestingPath("session-hooks/xephyr.desktop"), Xorg)
+	if x.getXServer() != "/usr/bin/Xephyr" {
+		t.Errorf("TestBuildXorgArgs: unexpected X server '%s'", x.getXServer())
+	}
+
+	args = strings.Join(x.buildXorgArgs("/usr/bin/Xephyr"), " ")
+	if args != ":1 -nolisten tcp -screen 1280x720 -dpi 192 -ardelay 200" {
+		t.Errorf("TestBuildXorgArgs: unexpected Xephyr arguments '%s'", args)
+	}
+}
+
+func TestBuildXorgArgsAsRoot(t *testing.T) {
+	c := &config{Tty: 7, XorgBinary: "/usr/bin/Xorg"}
+	u := &sysuser{env: map[string]string{envDisplay: ":1"}}
+	a := &testAuth{&authBase{}, u}
+	d := &desktop{path: getTestingPath("session-hooks/xephyr.desktop"), envOrigin: UserCustom, xServer: "/tmp/Xorg", xorgArgs: []string{"-modulepath", "/tmp"}}
+	x := &xorgSession{&commonSession{nil, a, d, c, nil, nil, false}, nil, nil}
+
+	if x.getXServer() != "/usr/bin/Xorg" {
+		t.Errorf("TestBuildXorgArgsAsRoot: X server of user session should be ignored, but was '%s'", x.getXServer())
+	}
+
+	if args := strings.Join(x.buildXorgArgs("/usr/bin/Xorg"), " "); args != "vt7 :1" {
+		t.Errorf("TestBuildXorgArgsAsRoot: Xorg arguments of user session should be ignored, but were '%s'", args)
+	}
+
+	d.envOrigin = Xorg
+	d.path = t.TempDir() + "/writable.desktop"
+	os.WriteFile(d.path, []byte("[Desktop Entry]\n"), 0666)
+	os.Chmod(d.path, 0666)
+	if args := strings.Join(x.buildXorgArgs("/usr/bin/Xorg"), " "); args != "vt7 :1" {
+		t.Errorf("TestBuildXorgArgsAsRoot: Xorg arguments of writable session file should be ignored, but were '%s'", args)
+	}
+}
+
+func TestPrintDryRun(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+
+	c := &config{Tty: 7, DryRun: true, Lang: "en_US.UTF-8", UserLang: "de_DE.UTF-8", XorgArgs: "-nolisten tcp"}
+	u := &sysuser{uid: 3000, gid: 2000, username: "dry", homedir: t.TempDir(), env: map[string]string{}}
+	a := &testAuth{&authBase{}, u}
+	d := &desktop{name: "Dry Session", exec: "/usr/bin/dry-session --arg \"with space\"", execArgs: []string{"/usr/bin/dry-session", "--arg", "with space"}, env: Xorg, preExec: []string{"/usr/bin/pre"}, envVars: map[string]string{"DRY_RUN_VAR": "1"}}
+
+	var sb strings.Builder
+	readOutput(func() {
+		createSession(a, d, c).printDryRun(&sb)
+	})
+	output := sb.String()
+
+	for _, expected := range []string{
+		"Session: Dry Session (xorg)\n",
+		"Command: /usr/bin/dry-session --arg \"with space\"\n",
+		"X server: ",
+		" -nolisten tcp\n",
+		"PreExec: /usr/bin/pre\n",
+		"  LANG=de_DE.UTF-8\n",
+		"  DRY_RUN_VAR=1\n",
+		"  XDG_SESSION_TYPE=x11\n",
+		"  DISPLAY=:"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("TestPrintDryRun: output does not contain '%s'", expected)
+		}
+	}
+
+	if fileExists(u.getenv(envXdgRuntimeDir)) {
+		t.Error("TestPrintDryRun: XDG_RUNTIME_DIR should not be created")
+	}
+}
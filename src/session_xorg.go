@@ -7,7 +7,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strconv"
-	"syscall"
+	"strings"
 )
 
 const defaultXauthorityPath = ".Xauthority"
@@ -21,15 +21,9 @@ type xorgSession struct {
 
 // Starts Xorg as carrier for Xorg Session.
 func (x *xorgSession) startCarrier() {
-	if x.conf.DefaultXauthority {
-		x.auth.usr().setenv(envXauthority, filepath.Join(x.auth.usr().homedir, defaultXauthorityPath))
-	} else {
-		x.auth.usr().setenv(envXauthority, filepath.Join(x.auth.usr().getenv(envXdgRuntimeDir), ".emptty-xauth"))
-	}
+	x.defineXorgEnvironment()
 	os.Remove(x.auth.usr().getenv(envXauthority))
 
-	x.auth.usr().setenv(envDisplay, ":"+x.getFreeXDisplay())
-
 	// generate mcookie
 	cmd := cmdAsUser(x.auth.usr(), lookPath("mcookie", "/usr/bin/mcookie"))
 	mcookie, err := cmd.Output()
@@ -43,29 +37,21 @@ func (x *xorgSession) startCarrier() {
 	logPrint("Generated xauthority")
 
 	// start X
-	logPrint("Starting Xorg")
-
-	xorgArgs := []string{"vt" + x.conf.strTTY(), x.auth.usr().getenv(envDisplay)}
-	if x.allowRootlessX() {
-		xorgArgs = append(xorgArgs, "-keeptty")
-	}
-
-	if x.conf.XorgArgs != "" {
-		arrXorgArgs := parseExec(x.conf.XorgArgs)
-		xorgArgs = append(xorgArgs, arrXorgArgs...)
-	}
+	xServer := x.getXServer()
+	xorgArgs := x.buildXorgArgs(xServer)
+	logPrint("Starting " + xServer + " " + strings.Join(xorgArgs, " "))
 
 	if x.allowRootlessX() {
-		x.xorg = cmdAsUser(x.auth.usr(), lookPath("Xorg", "/usr/bin/Xorg"), xorgArgs...)
+		x.xorg = cmdAsUser(x.auth.usr(), xServer, xorgArgs...)
 		x.xorg.Env = x.auth.usr().environ()
 		if err := x.setTTYOwnership(x.conf, x.auth.usr().uid); err != nil {
 			logPrint(err)
 		}
 	} else {
-		x.xorg = exec.Command(lookPath("Xorg", "/usr/bin/Xorg"), xorgArgs...)
+		x.xorg = exec.Command(xServer, xorgArgs...)
 		os.Setenv(envDisplay, x.auth.usr().getenv(envDisplay))
 		os.Setenv(envXauthority, x.auth.usr().getenv(envXauthority))
-		x.xorg.Env = os.Environ()
+		x.xorg.Env = append(filterEnviron(x.conf, os.Environ()), envDisplay+"="+x.auth.usr().getenv(envDisplay), envXauthority+"="+x.auth.usr().getenv(envXauthority))
 	}
 
 	x.xorg.Start()
@@ -82,6 +68,79 @@ func (x *xorgSession) startCarrier() {
 	}
 }
 
+// Defines XAUTHORITY and DISPLAY of Xorg session.
+func (x *xorgSession) defineXorgEnvironment() {
+	if x.conf.DefaultXauthority {
+		x.auth.usr().setenv(envXauthority, filepath.Join(x.auth.usr().homedir, defaultXauthorityPath))
+	} else {
+		x.auth.usr().setenv(envXauthority, filepath.Join(x.auth.usr().getenv(envXdgRuntimeDir), ".emptty-xauth"))
+	}
+	x.auth.usr().setenv(envDisplay, ":"+x.getFreeXDisplay())
+}
+
+// Gets path to X server binary, session defined server has higher priority than XORG_BINARY.
+func (x *xorgSession) getXServer() string {
+	xServer := x.conf.XorgBinary
+	for _, d := range []*desktop{x.d, x.d.child} {
+		if d != nil && d.xServer != "" {
+			if !x.isDesktopXorgConfigAllowed(d) {
+				logPrintf("X server '%s' defined by %s is ignored, it is allowed only from system session file or with rootless Xorg", d.xServer, d.path)
+				continue
+			}
+			xServer = d.xServer
+			break
+		}
+	}
+
+	if xServer == "" {
+		return lookPath("Xorg", "/usr/bin/Xorg")
+	}
+	return lookPath(xServer, xServer)
+}
+
+// Builds arguments of X server, XORG_ARGS are followed by arguments defined by selected session and user configuration.
+func (x *xorgSession) buildXorgArgs(xServer string) []string {
+	var xorgArgs []string
+	isXorg := isXorgBinary(xServer)
+	if isXorg {
+		xorgArgs = append(xorgArgs, "vt"+x.conf.strTTY())
+	}
+	xorgArgs = append(xorgArgs, x.auth.usr().getenv(envDisplay))
+	if isXorg && x.allowRootlessX() {
+		xorgArgs = append(xorgArgs, "-keeptty")
+	}
+
+	if x.conf.XorgArgs != "" {
+		xorgArgs = append(xorgArgs, parseExec(x.conf.XorgArgs)...)
+	}
+	for _, d := range []*desktop{x.d.child, x.d} {
+		if d == nil || len(d.xorgArgs) == 0 {
+			continue
+		}
+		if !x.isDesktopXorgConfigAllowed(d) {
+			logPrintf("Xorg arguments '%s' defined by %s are ignored, they are allowed only from system session file or with rootless Xorg", strings.Join(d.xorgArgs, " "), d.path)
+			continue
+		}
+		xorgArgs = append(xorgArgs, d.xorgArgs...)
+	}
+	return xorgArgs
+}
+
+// Checks, if X server and its arguments defined by desktop could be used. X server started as root could be
+// configured only by system session file, that is owned by root and not writable by anyone else.
+func (x *xorgSession) isDesktopXorgConfigAllowed(d *desktop) bool {
+	if x.allowRootlessX() {
+		return true
+	}
+	return !d.isUser && d.envOrigin != UserCustom && isProtectedFile(d.path)
+}
+
+// Checks, if X server binary is Xorg, that handles virtual terminal arguments.
+func isXorgBinary(xServer string) bool {
+	name := filepath.Base(xServer)
+	return name == "Xorg" || name == "X"
+}
+
 // Gets Xorg Pid as int
 func (x *xorgSession) getCarrierPid() int {
 	if x.xorg == nil {
@@ -115,22 +174,6 @@ func (x *xorgSession) finishCarrier() error {
 	return err
 }
 
-// Sets TTY ownership to defined uid, but keeps the original gid.
-func (x *xorgSession) setTTYOwnership(conf *config, uid int) error {
-	info, err := os.Stat(conf.ttyPath())
-	if err != nil {
-		return err
-	}
-	stat := info.Sys().(*syscall.Stat_t)
-
-	err = os.Chown(conf.ttyPath(), uid, int(stat.Gid))
-	if err != nil {
-		return err
-	}
-	err = os.Chmod(conf.ttyPath(), 0620)
-	return err
-}
-
 // Finds free display for spawning Xorg instance.
 func (x *xorgSession) getFreeXDisplay() string {
 	for i := 0; i < 32; i++ {
@@ -0,0 +1,154 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+const constOriginUserConfig = "User Config"
+
+// sessionInfo defines single session, that could be offered to user.
+type sessionInfo struct {
+	Index       int    `json:"index"`
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	Environment string `json:"environment"`
+	Origin      string `json:"origin"`
+	Path        string `json:"path"`
+	Exec        string `json:"exec"`
+	Hidden      bool   `json:"hidden"`
+	NoDisplay   bool   `json:"noDisplay"`
+	Unavailable bool   `json:"unavailable"`
+	Offered     bool   `json:"offered"`
+	Preselected bool   `json:"preselected"`
+}
+
+// Prints sessions, that would be offered to user, as text or JSON. If username is empty, current user is used.
+func printSessionList(conf *config, username string, asJson bool) {
+	var u *user.User
+	var err error
+	if username != "" {
+		u, err = user.Lookup(username)
+	} else {
+		u, err = user.Current()
+	}
+	handleErr(err)
+
+	sessions := listSessions(conf, getSysuser(u))
+	if asJson {
+		err = fprintSessionListJson(os.Stdout, sessions)
+		handleErr(err)
+	} else {
+		fprintSessionList(os.Stdout, sessions)
+	}
+}
+
+// Lists sessions of user in the same order as they are offered in selection, sessions, that are not offered, follow.
+func listSessions(conf *config, usr *sysuser) []*sessionInfo {
+	userDesktop, usrLang := loadUserDesktop(usr.homedir)
+	if usrLang != "" {
+		setDesktopLocale(usrLang)
+	} else {
+		setDesktopLocale(conf.Lang)
+	}
+
+	var result []*sessionInfo
+	if userDesktop != nil {
+		info := newSessionInfo(userDesktop, -1)
+		info.Origin = constOriginUserConfig
+		info.Offered = true
+		info.Preselected = userDesktop.selection == SelectionFalse
+		result = append(result, info)
+	}
+
+	desktops := listAvailableDesktops(conf, usr)
+	if userDesktop == nil || userDesktop.selection != SelectionFalse {
+		preselected := findPreselectedDesktop(conf, userDesktop, desktops)
+		if preselected == nil && len(desktops) > 0 {
+			preselected = desktops[getLastDesktop(conf, usr, desktops)]
+		}
+		for i, d := range desktops {
+			info := newSessionInfo(d, i)
+			info.Offered = true
+			info.Preselected = d == preselected
+			result = append(result, info)
+		}
+	}
+
+	path := getUserSessionPath(conf, usr)
+	for _, d := range listAllDesktopsWithHidden(usr, conf.XorgSessionsPath, conf.WaylandSessionsPath, true) {
+		if isSessionListed(result, d) {
+			continue
+		}
+		d.unavailable = !d.isAvailable(path)
+		info := newSessionInfo(d, -1)
+		info.Hidden = info.Hidden || matchDesktop(d, conf.HiddenSessions) >= 0
+		result = append(result, info)
+	}
+	return result
+}
+
+// Creates session info from desktop.
+func newSessionInfo(d *desktop, index int) *sessionInfo {
+	strExec, _ := d.getStrExec()
+	return &sessionInfo{
+		Index:       index,
+		Id:          d.id,
+		Name:        d.name,
+		Environment: d.env.stringify(),
+		Origin:      d.envOrigin.string(),
+		Path:        d.path,
+		Exec:        strExec,
+		Hidden:      d.hidden,
+		NoDisplay:   d.noDisplay,
+		Unavailable: d.unavailable,
+	}
+}
+
+// Checks, if desktop is already listed as offered session.
+func isSessionListed(sessions []*sessionInfo, d *desktop) bool {
+	for _, s := range sessions {
+		if s.Offered && s.Path == d.path && s.Environment == d.env.stringify() {
+			return true
+		}
+	}
+	return false
+}
+
+// Prints sessions as text, one session per line.
+func fprintSessionList(w io.Writer, sessions []*sessionInfo) {
+	fmt.Fprintln(w, "INDEX\tNAME\tENV\tORIGIN\tPATH\tEXEC\tSTATE")
+	for _, s := range sessions {
+		index := "-"
+		if s.Index >= 0 {
+			index = strconv.Itoa(s.Index)
+		}
+
+		var state []string
+		for _, flag := range []struct {
+			value bool
+			name  string
+		}{{s.Preselected, "preselected"}, {!s.Offered, "not-offered"}, {s.Hidden, "hidden"}, {s.NoDisplay, "nodisplay"}, {s.Unavailable, "unavailable"}} {
+			if flag.value {
+				state = append(state, flag.name)
+			}
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", index, s.Name, s.Environment, s.Origin, s.Path, s.Exec, strings.Join(state, ","))
+	}
+}
+
+// Prints sessions as JSON array.
+func fprintSessionListJson(w io.Writer, sessions []*sessionInfo) error {
+	if sessions == nil {
+		sessions = []*sessionInfo{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sessions)
+}
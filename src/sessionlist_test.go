@@ -0,0 +1,52 @@
+package src
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListSessions(t *testing.T) {
+	t.Setenv(envXdgDataDirs, "/dev/null")
+	waylandDir := t.TempDir()
+	os.WriteFile(filepath.Join(waylandDir, "hidden.desktop"), []byte("[Desktop Entry]\nName=Hidden\nExec=/usr/bin/hidden\nNoDisplay=true\n"), 0644)
+
+	conf := &config{XorgSessionsPath: getTestingPath("desktops"), WaylandSessionsPath: waylandDir, UnavailableSessions: UnavailableShow, DefaultSession: "Desktop2"}
+	usr := &sysuser{homedir: t.TempDir(), env: map[string]string{}}
+
+	var sessions []*sessionInfo
+	readOutput(func() {
+		sessions = listSessions(conf, usr)
+	})
+	if len(sessions) != 3 {
+		t.Errorf("TestListSessions: unexpected count of sessions %d", len(sessions))
+		return
+	}
+
+	if sessions[0].Name != "Desktop1" || sessions[0].Index != 0 || !sessions[0].Offered || sessions[0].Preselected || sessions[0].Origin != "Xorg" {
+		t.Error("TestListSessions: unexpected first session")
+	}
+	if sessions[1].Name != "Desktop2" || sessions[1].Environment != "wayland" || !sessions[1].Preselected {
+		t.Error("TestListSessions: DEFAULT_SESSION should be preselected")
+	}
+	if sessions[2].Name != "Hidden" || sessions[2].Index != -1 || sessions[2].Offered || !sessions[2].NoDisplay {
+		t.Error("TestListSessions: session with NoDisplay should be listed as not offered")
+	}
+
+	var sb strings.Builder
+	fprintSessionList(&sb, sessions)
+	if lines := strings.Split(strings.TrimSpace(sb.String()), "\n"); len(lines) != 4 || !strings.HasSuffix(lines[2], "\tpreselected") || !strings.Contains(lines[3], "\tnot-offered,nodisplay") {
+		t.Errorf("TestListSessions: unexpected text output '%s'", sb.String())
+	}
+
+	sb.Reset()
+	if err := fprintSessionListJson(&sb, sessions); err != nil {
+		t.Error(err)
+	}
+	var parsed []map[string]interface{}
+	if err := json.Unmarshal([]byte(sb.String()), &parsed); err != nil || len(parsed) != 3 || parsed[1]["preselected"] != true || parsed[2]["path"] != filepath.Join(waylandDir, "hidden.desktop") {
+		t.Errorf("TestListSessions: unexpected JSON output '%s'", sb.String())
+	}
+}
@@ -0,0 +1,217 @@
+package src
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	pathSessionStats = "/.cache/emptty/session-stats"
+	pathPasswd       = "/etc/passwd"
+
+	constSessionSortName  = "name"
+	constSessionSortPath  = "path"
+	constSessionSortUsage = "usage"
+)
+
+type enSessionSort byte
+
+const (
+
+	// Sort sessions by their name
+	SessionSortName enSessionSort = iota
+
+	// Sort sessions by path of their desktop file
+	SessionSortPath
+
+	// Sort sessions by count of user's usage
+	SessionSortUsage
+)
+
+// sessionStat defines usage statistics of single session.
+type sessionStat struct {
+	id       string
+	env      enEnvironment
+	count    int
+	lastUsed int64
+}
+
+// Parses session sort config option.
+func parseSessionSort(value, defaultValue string) enSessionSort {
+	switch strings.ToLower(sanitizeValue(value, defaultValue)) {
+	case constSessionSortPath:
+		return SessionSortPath
+	case constSessionSortUsage:
+		return SessionSortUsage
+	}
+	return SessionSortName
+}
+
+// Stringify enSessionSort value.
+func (s enSessionSort) stringify() string {
+	return []string{constSessionSortName, constSessionSortPath, constSessionSortUsage}[int(s)]
+}
+
+// Reads session statistics from path, each line is expected as ENV;COUNT;LAST_USED;ID.
+func readSessionStats(path string) []*sessionStat {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var result []*sessionStat
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		arr := strings.SplitN(strings.TrimSpace(scanner.Text()), ";", 4)
+		if len(arr) != 4 || arr[3] == "" {
+			continue
+		}
+		count, err := strconv.Atoi(arr[1])
+		if err != nil {
+			continue
+		}
+		lastUsed, _ := strconv.ParseInt(arr[2], 10, 64)
+		result = append(result, &sessionStat{id: arr[3], env: parseEnv(arr[0], defaultEnv()), count: count, lastUsed: lastUsed})
+	}
+	return result
+}
+
+// Writes session statistics into path.
+func writeSessionStats(path string, stats []*sessionStat) error {
+	var sb strings.Builder
+	for _, s := range stats {
+		sb.WriteString(fmt.Sprintf("%s;%d;%d;%s\n", s.env.stringify(), s.count, s.lastUsed, s.id))
+	}
+
+	if err := mkDirsForFile(path, 0744); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(sb.String()), 0600)
+}
+
+// Finds statistics of desktop.
+func findSessionStat(stats []*sessionStat, d *desktop) *sessionStat {
+	for _, s := range stats {
+		if s.id == d.id && s.env == d.env {
+			return s
+		}
+	}
+	return nil
+}
+
+// Increases usage count of desktop and updates its last usage in user's session statistics.
+func updateSessionStats(usr *sysuser, d *desktop, now time.Time) {
+	doAsUser(usr, func() {
+		path := usr.homedir + pathSessionStats
+		stats := readSessionStats(path)
+
+		s := findSessionStat(stats, d)
+		if s == nil {
+			s = &sessionStat{id: d.id, env: d.env}
+			stats = append(stats, s)
+		}
+		s.count++
+		s.lastUsed = now.Unix()
+
+		if err := writeSessionStats(path, stats); err != nil {
+			logPrint(err)
+		}
+	})
+}
+
+// Loads user's session statistics into desktops, that are used for sorting by usage.
+func loadSessionStats(usr *sysuser, desktops []*desktop) {
+	stats := readSessionStats(usr.homedir + pathSessionStats)
+	for _, d := range desktops {
+		d.usage = findSessionStat(stats, d)
+	}
+}
+
+// Compares usage of desktops, the first value is true if desktop a is used more than desktop b, the second one is true if usage differs.
+func compareSessionUsage(a, b *desktop) (bool, bool) {
+	countA, countB, lastA, lastB := 0, 0, int64(0), int64(0)
+	if a.usage != nil {
+		countA, lastA = a.usage.count, a.usage.lastUsed
+	}
+	if b.usage != nil {
+		countB, lastB = b.usage.count, b.usage.lastUsed
+	}
+
+	if countA != countB {
+		return countA > countB, true
+	}
+	if lastA != lastB {
+		return lastA > lastB, true
+	}
+	return false, false
+}
+
+// Prints session statistics of user or of all users with any statistics, if username is empty.
+func printSessionStats(username string) {
+	homes := make(map[string]string)
+	var usernames []string
+	if username != "" {
+		u, err := user.Lookup(username)
+		handleErr(err)
+		homes[u.Username] = u.HomeDir
+		usernames = append(usernames, u.Username)
+	} else {
+		err := readPasswd(pathPasswd, func(name, homedir string) {
+			if _, exists := homes[name]; !exists && fileExists(homedir+pathSessionStats) {
+				homes[name] = homedir
+				usernames = append(usernames, name)
+			}
+		})
+		handleErr(err)
+	}
+
+	sort.Strings(usernames)
+	fmt.Println("USER\tSESSION\tENV\tCOUNT\tLAST_USED")
+	for _, name := range usernames {
+		fprintSessionStats(os.Stdout, name, readSessionStats(homes[name]+pathSessionStats))
+	}
+}
+
+// Prints session statistics of single user into writer, most used sessions first.
+func fprintSessionStats(w io.Writer, username string, stats []*sessionStat) {
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].count != stats[j].count {
+			return stats[i].count > stats[j].count
+		}
+		return stats[i].lastUsed > stats[j].lastUsed
+	})
+
+	for _, s := range stats {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", username, s.id, s.env.stringify(), s.count, time.Unix(s.lastUsed, 0).Format(time.RFC3339))
+	}
+}
+
+// Reads passwd file and invokes method with name and home directory of each user.
+func readPasswd(path string, method func(name, homedir string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		arr := strings.Split(line, ":")
+		if len(arr) >= 6 && arr[0] != "" && arr[5] != "" {
+			method(arr[0], arr[5])
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,78 @@
+package src
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSessionSort(t *testing.T) {
+	for value, expected := range map[string]enSessionSort{"": SessionSortName, "USAGE": SessionSortUsage, "path": SessionSortPath, "unknown": SessionSortName} {
+		if result := parseSessionSort(value, "name"); result != expected {
+			t.Errorf("TestParseSessionSort: unexpected result for '%s'", value)
+		}
+	}
+
+	if SessionSortUsage.stringify() != "usage" {
+		t.Error("TestParseSessionSort: unexpected stringify result")
+	}
+}
+
+func TestUpdateSessionStats(t *testing.T) {
+	currentUser, _ := user.Current()
+	usr := getSysuser(currentUser)
+	usr.homedir = t.TempDir()
+
+	sway := &desktop{id: "sway.desktop", env: Wayland}
+	i3 := &desktop{id: "i3.desktop", env: Xorg}
+
+	updateSessionStats(usr, sway, time.Unix(100, 0))
+	updateSessionStats(usr, i3, time.Unix(200, 0))
+	updateSessionStats(usr, sway, time.Unix(300, 0))
+
+	stats := readSessionStats(usr.homedir + pathSessionStats)
+	if len(stats) != 2 {
+		t.Errorf("TestUpdateSessionStats: unexpected count of statistics %d", len(stats))
+		return
+	}
+	if s := findSessionStat(stats, sway); s == nil || s.count != 2 || s.lastUsed != 300 {
+		t.Error("TestUpdateSessionStats: unexpected statistics of sway")
+	}
+	if s := findSessionStat(stats, &desktop{id: "i3.desktop", env: Wayland}); s != nil {
+		t.Error("TestUpdateSessionStats: statistics should be distinguished by environment")
+	}
+
+	desktops := []*desktop{{id: "i3.desktop", name: "i3", env: Xorg}, {id: "awesome.desktop", name: "Awesome", env: Xorg}, {id: "sway.desktop", name: "Sway", env: Wayland}}
+	loadSessionStats(usr, desktops)
+	var names []string
+	for _, d := range arrangeDesktops(&config{SessionSort: SessionSortUsage}, desktops) {
+		names = append(names, d.name)
+	}
+	if strings.Join(names, ",") != "Sway,i3,Awesome" {
+		t.Errorf("TestUpdateSessionStats: unexpected order by usage '%s'", strings.Join(names, ","))
+	}
+
+	var sb strings.Builder
+	fprintSessionStats(&sb, "test", stats)
+	if !strings.HasPrefix(sb.String(), "test\tsway.desktop\twayland\t2\t") {
+		t.Errorf("TestUpdateSessionStats: unexpected output '%s'", sb.String())
+	}
+}
+
+func TestReadPasswd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passwd")
+	os.WriteFile(path, []byte("root:x:0:0:root:/root:/bin/bash\n# comment\nbroken\nuser:x:1000:1000::/home/user:/bin/sh\n"), 0600)
+
+	var result []string
+	if err := readPasswd(path, func(name, homedir string) {
+		result = append(result, name+"="+homedir)
+	}); err != nil {
+		t.Error(err)
+	}
+	if strings.Join(result, ",") != "root=/root,user=/home/user" {
+		t.Errorf("TestReadPasswd: unexpected result '%s'", strings.Join(result, ","))
+	}
+}
@@ -0,0 +1,85 @@
+package src
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const shellEnvMarker = "__EMPTTY_SHELL_ENV__"
+
+// Environmental variables defined by shell itself, that are not imported.
+var ignoredShellEnvKeys = []string{envPwd, "OLDPWD", "SHLVL", "_"}
+
+// Runs user's login shell once to capture its exported environment and merges it into user's environment.
+func importShellEnviron(usr *sysuser, shell string, timeout int) {
+	output, err := runLoginShellEnv(usr, shell, time.Duration(timeout)*time.Second)
+	if err != nil {
+		logPrintf("Could not import environment from login shell %s: %v", shell, err)
+		return
+	}
+
+	for key, value := range parseShellEnvOutput(output) {
+		if contains(protectedEnvKeys, key) || contains(ignoredShellEnvKeys, key) {
+			continue
+		}
+		usr.setenv(key, value)
+	}
+	logPrint("Imported environment from login shell")
+}
+
+// Runs login shell as user with defined timeout and returns its output with printed environment.
+func runLoginShellEnv(usr *sysuser, shell string, timeout time.Duration) ([]byte, error) {
+	var stdout bytes.Buffer
+
+	cmd := cmdAsUser(usr, shell, "-c", "echo "+shellEnvMarker+"; env -0")
+	// Leading dash in argv[0] makes shell to behave as login shell
+	cmd.Args[0] = "-" + filepath.Base(cmd.Args[0])
+	cmd.Dir = usr.homedir
+	cmd.Stdout = &stdout
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	c := make(chan error, 1)
+	go func() {
+		c <- cmd.Wait()
+	}()
+
+	select {
+	case <-time.After(timeout):
+		syscall.Kill(cmd.Process.Pid, syscall.SIGKILL)
+		<-c
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	case err := <-c:
+		return stdout.Bytes(), err
+	}
+}
+
+// Parses output of "env -0" printed after marker. If output is not NUL separated, it is parsed per line.
+func parseShellEnvOutput(output []byte) map[string]string {
+	result := make(map[string]string)
+
+	if i := bytes.Index(output, []byte(shellEnvMarker+"\n")); i > -1 {
+		output = output[i+len(shellEnvMarker)+1:]
+	} else {
+		return result
+	}
+
+	separator := "\x00"
+	if !bytes.Contains(output, []byte(separator)) {
+		separator = "\n"
+	}
+
+	for _, e := range strings.Split(string(output), separator) {
+		if i := strings.Index(e, "="); i > 0 {
+			result[e[:i]] = e[i+1:]
+		}
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package src
+
+import (
+	"os/user"
+	"testing"
+	"time"
+)
+
+func TestParseShellEnvOutput(t *testing.T) {
+	env := parseShellEnvOutput([]byte("motd\n" + shellEnvMarker + "\nA=1\x00B=multi\nline\x00C=\x00"))
+	if len(env) != 3 || env["A"] != "1" || env["B"] != "multi\nline" || env["C"] != "" {
+		t.Errorf("TestParseShellEnvOutput: unexpected result for NUL separated output %v", env)
+	}
+
+	env = parseShellEnvOutput([]byte(shellEnvMarker + "\nA=1\nB=2=3\n"))
+	if len(env) != 2 || env["A"] != "1" || env["B"] != "2=3" {
+		t.Errorf("TestParseShellEnvOutput: unexpected result for line separated output %v", env)
+	}
+
+	env = parseShellEnvOutput([]byte("A=1\x00B=2"))
+	if len(env) != 0 {
+		t.Errorf("TestParseShellEnvOutput: no result was expected without marker %v", env)
+	}
+}
+
+func TestImportShellEnviron(t *testing.T) {
+	currentUser, _ := user.Current()
+	usr := getSysuser(currentUser)
+	usr.homedir = getTestingPath("userHome4")
+	usr.setenv(envHome, usr.homedir)
+	usr.setenv(envPath, "/usr/bin:/bin")
+
+	importShellEnviron(usr, "/bin/sh", 5)
+
+	if usr.getenv("EMPTTY_PROFILE") != "loaded from profile" {
+		t.Errorf("TestImportShellEnviron: variable from .profile was not imported, value was '%s'", usr.getenv("EMPTTY_PROFILE"))
+	}
+
+	if usr.getenv(envHome) != usr.homedir {
+		t.Error("TestImportShellEnviron: HOME should not be overridden")
+	}
+
+	if usr.getenv("SHLVL") != "" {
+		t.Error("TestImportShellEnviron: SHLVL should not be imported")
+	}
+}
+
+func TestRunLoginShellEnvTimeout(t *testing.T) {
+	currentUser, _ := user.Current()
+	usr := getSysuser(currentUser)
+	usr.homedir = getTestingPath("userHome5")
+	usr.setenv(envHome, usr.homedir)
+	usr.setenv(envPath, "/usr/bin:/bin")
+
+	start := time.Now()
+	if _, err := runLoginShellEnv(usr, "/bin/sh", 100*time.Millisecond); err == nil {
+		t.Error("TestRunLoginShellEnvTimeout: error was expected")
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Error("TestRunLoginShellEnvTimeout: timeout was not applied")
+	}
+}
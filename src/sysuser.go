@@ -17,6 +17,7 @@ type sysuser struct {
 	gids     []int
 	gidsu32  []uint32
 	env      map[string]string
+	lookPath string
 }
 
 // Loads all necessary info about user into sysuser struct.
@@ -82,6 +83,11 @@ func (u *sysuser) setenvIfEmpty(key, value string) {
 	}
 }
 
+// unsets user's environmental variable.
+func (u *sysuser) unsetenv(key string) {
+	delete(u.env, strings.TrimSpace(key))
+}
+
 // returns a copy of environmental variables.
 func (u *sysuser) environ() []string {
 	var result []string
@@ -0,0 +1,343 @@
+package src
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	pathTimeLimits = "/etc/emptty/time-limits"
+	pathTimeUsage  = "/var/lib/emptty/usage/"
+
+	timeLimitDefault      = "*"
+	timeLimitHours        = "HOURS"
+	timeLimitDailyMinutes = "DAILY_MINUTES"
+	timeUsageDate         = "DATE"
+	timeUsageSeconds      = "SECONDS"
+	timeUsageDateFormat   = "2006-01-02"
+
+	envRemainingMinutes = "EMPTTY_REMAINING_MINUTES"
+
+	timeLimitKillTimeout = 30 * time.Second
+)
+
+var errLoginNotAllowed = errors.New("login is not allowed at this time")
+var errDailyLimitReached = errors.New("daily time limit was reached")
+
+// timeRange defines allowed login window in seconds from midnight, window could continue over midnight.
+type timeRange struct {
+	from int
+	to   int
+}
+
+// timeLimit defines allowed login windows and daily budget of user, negative dailyMinutes means unlimited.
+type timeLimit struct {
+	hours        []timeRange
+	dailyMinutes int
+}
+
+// Loads time limit of user from path. Section [*] defines default values, that could be overridden by user's section.
+// If there is no matching section, nil is returned.
+func loadTimeLimit(path, username string) *timeLimit {
+	if !fileExists(path) {
+		return nil
+	}
+
+	values := make(map[string]map[string]string)
+	err := readPropertiesWithSections(path, func(section, key, value string) {
+		if section != timeLimitDefault && section != username {
+			return
+		}
+		if values[section] == nil {
+			values[section] = make(map[string]string)
+		}
+		values[section][key] = value
+	}, false)
+	if err != nil {
+		logPrint(err)
+		return nil
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	l := &timeLimit{dailyMinutes: -1}
+	for _, section := range []string{timeLimitDefault, username} {
+		for key, value := range values[section] {
+			switch key {
+			case timeLimitHours:
+				hours, err := parseTimeRanges(value)
+				if err != nil {
+					logPrintf("%s: %s", path, err)
+					continue
+				}
+				l.hours = hours
+			case timeLimitDailyMinutes:
+				if value == "" {
+					l.dailyMinutes = -1
+				} else if minutes, err := strconv.Atoi(value); err == nil {
+					l.dailyMinutes = minutes
+				} else {
+					logPrintf("%s: wrong value of %s '%s'", path, key, value)
+				}
+			}
+		}
+	}
+	return l
+}
+
+// Parses comma separated time ranges in format HH:MM-HH:MM.
+func parseTimeRanges(value string) ([]timeRange, error) {
+	var result []timeRange
+	for _, strRange := range strings.Split(value, ",") {
+		strRange = strings.TrimSpace(strRange)
+		if strRange == "" {
+			continue
+		}
+		strFrom, strTo, found := strings.Cut(strRange, "-")
+		if !found {
+			return nil, errors.New("wrong time range '" + strRange + "'")
+		}
+		from, err := parseTimeOfDay(strFrom)
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseTimeOfDay(strTo)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, timeRange{from, to})
+	}
+	return result, nil
+}
+
+// Parses time of day in format HH:MM into seconds from midnight, 24:00 is allowed as end of day.
+func parseTimeOfDay(value string) (int, error) {
+	strHours, strMinutes, found := strings.Cut(strings.TrimSpace(value), ":")
+	hours, errHours := strconv.Atoi(strHours)
+	minutes, errMinutes := strconv.Atoi(strMinutes)
+	if !found || errHours != nil || errMinutes != nil || hours < 0 || minutes < 0 || minutes > 59 || hours > 24 || (hours == 24 && minutes > 0) {
+		return 0, errors.New("wrong time '" + value + "'")
+	}
+	return hours*3600 + minutes*60, nil
+}
+
+// Gets remaining time of session according to allowed windows and daily budget. Negative value means unlimited time.
+// If login is not allowed, error is returned.
+func (l *timeLimit) getRemaining(now time.Time, usedSeconds int) (time.Duration, error) {
+	remaining := -1
+	if len(l.hours) > 0 {
+		second := now.Hour()*3600 + now.Minute()*60 + now.Second()
+		for _, r := range l.hours {
+			if untilEnd := r.getUntilEnd(second); untilEnd > remaining {
+				remaining = untilEnd
+			}
+		}
+		if remaining <= 0 {
+			return 0, errLoginNotAllowed
+		}
+	}
+
+	if l.dailyMinutes >= 0 {
+		budget := l.dailyMinutes*60 - usedSeconds
+		if budget <= 0 {
+			return 0, errDailyLimitReached
+		}
+		if remaining < 0 || budget < remaining {
+			remaining = budget
+		}
+	}
+
+	if remaining < 0 {
+		return -1, nil
+	}
+	return time.Duration(remaining) * time.Second, nil
+}
+
+// Gets seconds until end of time range, if second of day is inside of it. Otherwise 0 is returned.
+func (r timeRange) getUntilEnd(second int) int {
+	switch {
+	case r.from < r.to && second >= r.from && second < r.to:
+		return r.to - second
+	case r.from > r.to && second >= r.from:
+		return 24*3600 - second + r.to
+	case r.from > r.to && second < r.to:
+		return r.to - second
+	}
+	return 0
+}
+
+// Reads used seconds of day defined by now, usage of other days is ignored.
+func readTimeUsage(path string, now time.Time) int {
+	values, err := readPropertiesToMap(path)
+	if err != nil || values[timeUsageDate] != now.Format(timeUsageDateFormat) {
+		return 0
+	}
+	seconds, _ := strconv.Atoi(values[timeUsageSeconds])
+	return seconds
+}
+
+// Adds time between from and to into usage of day defined by to, time before its midnight is not counted.
+func addTimeUsage(path string, from, to time.Time) {
+	if midnight := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, to.Location()); from.Before(midnight) {
+		from = midnight
+	}
+	seconds := int(to.Sub(from).Round(time.Second).Seconds())
+	if seconds <= 0 {
+		return
+	}
+
+	data := fmt.Sprintf("%s=%s\n%s=%d\n", timeUsageDate, to.Format(timeUsageDateFormat), timeUsageSeconds, readTimeUsage(path, to)+seconds)
+	if err := mkDirsForFile(path, 0700); err != nil {
+		logPrint(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		logPrint(err)
+	}
+}
+
+// Checks, if error was caused by time limit.
+func isTimeLimitErr(err error) bool {
+	return errors.Is(err, errLoginNotAllowed) || errors.Is(err, errDailyLimitReached)
+}
+
+// Checks, if user is allowed to log in now according to time limits.
+func checkLoginTimeLimit(username string) error {
+	if _, err := getTimeLimitRemaining(username, time.Now()); err != nil {
+		logPrintf("Login of %s was refused: %s", username, err)
+		return err
+	}
+	return nil
+}
+
+// Gets remaining time of user's session defined in time limits. Negative value means unlimited time.
+func getTimeLimitRemaining(username string, now time.Time) (time.Duration, error) {
+	l := loadTimeLimit(pathTimeLimits, username)
+	if l == nil {
+		return -1, nil
+	}
+	return l.getRemaining(now, readTimeUsage(pathTimeUsage+username, now))
+}
+
+// Starts watching of session time limit. Usage is saved each minute and remaining time is recomputed at midnight,
+// user is warned by TIME_LIMIT_WARN_CMD and session is ended, when time limit is reached. Returned function stops
+// watching and saves usage.
+func (s *commonSession) startTimeLimit() func() {
+	username := s.auth.usr().username
+	if loadTimeLimit(pathTimeLimits, username) == nil {
+		return func() {}
+	}
+
+	done := make(chan bool)
+	finished := make(chan bool)
+	go func() {
+		usagePath := pathTimeUsage + username
+		lastSave := time.Now()
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		var warnC, endC, dayC, killC <-chan time.Time
+		var end time.Time
+		schedule := func(now time.Time) {
+			remaining, err := getTimeLimitRemaining(username, now)
+			if err != nil {
+				remaining = 0
+			}
+			warnC, endC = nil, nil
+			if remaining >= 0 {
+				logPrintf("Session is limited to %s", remaining.Round(time.Second))
+				end = now.Add(remaining)
+				endC = time.After(remaining)
+				warnBefore := time.Duration(s.conf.TimeLimitWarnBefore) * time.Minute
+				if s.conf.TimeLimitWarnCmd != "" && warnBefore > 0 && remaining > warnBefore {
+					warnC = time.After(remaining - warnBefore)
+				}
+			}
+			midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+			dayC = time.After(midnight.Sub(now))
+		}
+		saveUsage := func(now time.Time) {
+			addTimeUsage(usagePath, lastSave, now)
+			lastSave = now
+		}
+		schedule(lastSave)
+
+		for {
+			select {
+			case <-done:
+				saveUsage(time.Now())
+				finished <- true
+				return
+			case now := <-ticker.C:
+				saveUsage(now)
+			case now := <-dayC:
+				saveUsage(now)
+				schedule(now)
+			case now := <-warnC:
+				warnC = nil
+				s.runTimeLimitWarning(end.Sub(now))
+			case now := <-endC:
+				endC = nil
+				saveUsage(now)
+				if _, err := getTimeLimitRemaining(username, now); err == nil {
+					schedule(now)
+					continue
+				}
+				logPrint("Time limit was reached, ending session")
+				if s.cmd != nil && s.cmd.Process != nil {
+					s.interrupted = true
+					if err := signalProcessGroup(s.cmd.Process.Pid, syscall.SIGTERM); err != nil {
+						logPrint(err)
+					}
+					killC = time.After(timeLimitKillTimeout)
+				}
+			case <-killC:
+				killC = nil
+				logPrint("Session did not end after time limit, killing it")
+				if err := signalProcessGroup(s.cmd.Process.Pid, syscall.SIGKILL); err != nil {
+					logPrint(err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		done <- true
+		<-finished
+	}
+}
+
+// Sends signal to whole process group, if process leads its own group. Otherwise only process is signalled.
+func signalProcessGroup(pid int, sig syscall.Signal) error {
+	if pgid, err := syscall.Getpgid(pid); err == nil && pgid == pid {
+		return syscall.Kill(-pid, sig)
+	}
+	return syscall.Kill(pid, sig)
+}
+
+// Runs TIME_LIMIT_WARN_CMD as user with remaining minutes defined in EMPTTY_REMAINING_MINUTES.
+func (s *commonSession) runTimeLimitWarning(remaining time.Duration) {
+	args := parseExec(s.conf.TimeLimitWarnCmd)
+	if len(args) == 0 {
+		return
+	}
+
+	minutes := int(remaining.Round(time.Minute).Minutes())
+	cmd := cmdArgsAsUser(s.auth.usr(), args)
+	cmd.Env = append(cmd.Env, envRemainingMinutes+"="+strconv.Itoa(minutes))
+	cmd.Dir = s.auth.usr().homedir
+	if err := cmd.Start(); err != nil {
+		logPrint("error during start of time limit warning ", err)
+		return
+	}
+	go func() {
+		if err := waitWithTimeout(cmd, sessionHookTimeout); err != nil {
+			logPrint("time limit warning finished with error ", err)
+		}
+	}()
+}
@@ -0,0 +1,139 @@
+package src
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseTimeRanges(t *testing.T) {
+	ranges, err := parseTimeRanges("08:00-12:30, 22:00-24:00,")
+	if err != nil || len(ranges) != 2 {
+		t.Fatal("TestParseTimeRanges: unexpected result")
+	}
+	if ranges[0].from != 8*3600 || ranges[0].to != 12*3600+30*60 || ranges[1].to != 24*3600 {
+		t.Error("TestParseTimeRanges: unexpected parsed values")
+	}
+
+	for _, value := range []string{"08:00", "8-12", "08:00-25:00", "08:60-12:00", "24:01-01:00"} {
+		if _, err := parseTimeRanges(value); err == nil {
+			t.Errorf("TestParseTimeRanges: expected error for '%s'", value)
+		}
+	}
+}
+
+func TestLoadTimeLimit(t *testing.T) {
+	path := getTestingPath("time-limits")
+
+	l := loadTimeLimit(path, "user")
+	if l == nil || len(l.hours) != 1 || l.hours[0].from != 8*3600 || l.dailyMinutes != 120 {
+		t.Error("TestLoadTimeLimit: default limit was not loaded")
+	}
+
+	l = loadTimeLimit(path, "kid")
+	if l == nil || len(l.hours) != 2 || l.hours[1].from != 22*3600 || l.hours[1].to != 3600 || l.dailyMinutes != 120 {
+		t.Error("TestLoadTimeLimit: user's limit was not merged with default limit")
+	}
+
+	l = loadTimeLimit(path, "admin")
+	if l == nil || len(l.hours) != 0 || l.dailyMinutes != -1 {
+		t.Error("TestLoadTimeLimit: user's limit did not clear default limit")
+	}
+
+	if loadTimeLimit(getTestingPath("time-limits-missing"), "user") != nil {
+		t.Error("TestLoadTimeLimit: missing file should not limit anybody")
+	}
+}
+
+func TestGetRemaining(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 5, 10, hour, minute, 0, 0, time.Local)
+	}
+	l := &timeLimit{hours: []timeRange{{14 * 3600, 18 * 3600}, {22 * 3600, 3600}}, dailyMinutes: 120}
+
+	if remaining, err := l.getRemaining(at(17, 0), 0); err != nil || remaining != time.Hour {
+		t.Error("TestGetRemaining: unexpected remaining time inside of window")
+	}
+	if remaining, err := l.getRemaining(at(15, 0), 100*60); err != nil || remaining != 20*time.Minute {
+		t.Error("TestGetRemaining: remaining time should be limited by daily budget")
+	}
+	if remaining, err := l.getRemaining(at(23, 30), 0); err != nil || remaining != 90*time.Minute {
+		t.Error("TestGetRemaining: unexpected remaining time of window over midnight")
+	}
+	if remaining, err := l.getRemaining(at(0, 30), 0); err != nil || remaining != 30*time.Minute {
+		t.Error("TestGetRemaining: unexpected remaining time after midnight")
+	}
+	if _, err := l.getRemaining(at(20, 0), 0); !errors.Is(err, errLoginNotAllowed) {
+		t.Error("TestGetRemaining: login should not be allowed outside of windows")
+	}
+	if _, err := l.getRemaining(at(15, 0), 120*60); !errors.Is(err, errDailyLimitReached) {
+		t.Error("TestGetRemaining: login should not be allowed after daily limit")
+	}
+
+	unlimited := &timeLimit{dailyMinutes: -1}
+	if remaining, err := unlimited.getRemaining(at(3, 0), 1000*60); err != nil || remaining >= 0 {
+		t.Error("TestGetRemaining: time should be unlimited")
+	}
+}
+
+func TestTimeUsage(t *testing.T) {
+	path := t.TempDir() + "/usage/user"
+	day := time.Date(2024, 5, 10, 10, 0, 0, 0, time.Local)
+
+	if readTimeUsage(path, day) != 0 {
+		t.Error("TestTimeUsage: missing usage should be empty")
+	}
+
+	addTimeUsage(path, day, day.Add(30*time.Minute))
+	addTimeUsage(path, day.Add(time.Hour), day.Add(time.Hour+90*time.Second))
+	if readTimeUsage(path, day) != 31*60+30 {
+		t.Error("TestTimeUsage: usage was not summed")
+	}
+
+	nextDay := time.Date(2024, 5, 11, 0, 10, 0, 0, time.Local)
+	if readTimeUsage(path, nextDay) != 0 {
+		t.Error("TestTimeUsage: usage of previous day should be ignored")
+	}
+
+	addTimeUsage(path, nextDay.Add(-time.Hour), nextDay)
+	if readTimeUsage(path, nextDay) != 10*60 {
+		t.Error("TestTimeUsage: only usage after midnight should be counted")
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Error("TestTimeUsage: usage file was not created")
+	}
+}
+
+func TestSignalProcessGroup(t *testing.T) {
+	var sb strings.Builder
+	cmd := exec.Command("/bin/sh", "-c", "sleep 10; echo finished")
+	cmd.Stdout = &sb
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatal("TestSignalProcessGroup: could not start process")
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if err := signalProcessGroup(cmd.Process.Pid, syscall.SIGTERM); err != nil {
+		t.Errorf("TestSignalProcessGroup: unexpected error %v", err)
+	}
+
+	c := make(chan error, 1)
+	go func() {
+		c <- cmd.Wait()
+	}()
+	select {
+	case <-c:
+		if sb.String() != "" {
+			t.Error("TestSignalProcessGroup: process should not finish by itself")
+		}
+	case <-time.After(2 * time.Second):
+		cmd.Process.Kill()
+		t.Error("TestSignalProcessGroup: child of process should be signalled as well")
+	}
+}
@@ -38,6 +38,9 @@ const (
 // propertyFunc defines method to be invoked during readProperties method for each record.
 type propertyFunc func(key, value string)
 
+// sectionPropertyFunc defines method to be invoked during readPropertiesWithSections method for each record.
+type sectionPropertyFunc func(section, key, value string)
+
 // readProperties reads defined filePath per line and parses each key-value pair.
 // These pairs are used as parameters for invoking propertyFunc
 func readProperties(filePath string, method propertyFunc) error {
@@ -47,6 +50,21 @@ func readProperties(filePath string, method propertyFunc) error {
 // readPropertiesWithSupport reads defined filePath per line and parses each key-value pair with possible fish shell support.
 // These pairs are used as parameters for invoking propertyFunc
 func readPropertiesWithSupport(filePath string, method propertyFunc, fishSupport bool) error {
+	return readPropertiesWithSections(filePath, func(section, key, value string) {
+		method(key, value)
+	}, fishSupport)
+}
+
+// readPropertiesWithSections reads defined filePath per line and parses each key-value pair with possible fish shell support.
+// Each pair is passed into sectionPropertyFunc with name of section defined by [name] header, pairs before first header have empty section.
+func readPropertiesWithSections(filePath string, method sectionPropertyFunc, fishSupport bool) error {
+	return readRawPropertiesWithSections(filePath, func(section, key, value string) {
+		method(section, strings.ToUpper(key), value)
+	}, fishSupport)
+}
+
+// readRawPropertiesWithSections works same as readPropertiesWithSections, but keys keep their original case.
+func readRawPropertiesWithSections(filePath string, method sectionPropertyFunc, fishSupport bool) error {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return errors.New("Could not open file " + filePath)
@@ -56,6 +74,7 @@ func readPropertiesWithSupport(filePath string, method propertyFunc, fishSupport
 	scanner := bufio.NewScanner(file)
 	requiresFishSupport := false
 	isFirstLine := true
+	section := ""
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if isFirstLine {
@@ -65,14 +84,40 @@ func readPropertiesWithSupport(filePath string, method propertyFunc, fishSupport
 			isFirstLine = false
 		}
 
-		readPropertyLine(line, method, requiresFishSupport)
+		if name, ok := parseSectionHeader(line); ok {
+			section = name
+			continue
+		}
+
+		readRawPropertyLine(line, func(key, value string) {
+			method(section, key, value)
+		}, requiresFishSupport)
 	}
 	return scanner.Err()
 }
 
+// Parses section header in form [name], name could not contain any whitespace.
+func parseSectionHeader(line string) (string, bool) {
+	if len(line) < 3 || !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return "", false
+	}
+	name := line[1 : len(line)-1]
+	if strings.ContainsAny(name, " \t[]=") {
+		return "", false
+	}
+	return name, true
+}
+
 // Reads single property line and parses its content into key-value pair.
 // The pair is used as parameter for invoking propertyFunc.
 func readPropertyLine(line string, method propertyFunc, fishSupport bool) {
+	readRawPropertyLine(line, func(key, value string) {
+		method(strings.ToUpper(key), value)
+	}, fishSupport)
+}
+
+// Reads single property line and parses its content into key-value pair, key keeps its original case.
+func readRawPropertyLine(line string, method propertyFunc, fishSupport bool) {
 	if !strings.HasPrefix(line, "#") && ((!fishSupport && strings.Contains(line, "=")) || fishSupport && strings.HasPrefix(line, "set")) {
 		var splitIndex int
 		if fishSupport {
@@ -87,7 +132,7 @@ func readPropertyLine(line string, method propertyFunc, fishSupport bool) {
 		if strings.Contains(value, "#") {
 			value = value[:strings.Index(value, "#")]
 		}
-		key = strings.ToUpper(strings.TrimSpace(key))
+		key = strings.TrimSpace(key)
 		value = strings.TrimSpace(value)
 		for (strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"")) || (strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'")) {
 			value = value[1 : len(value)-1]
@@ -121,6 +166,19 @@ func fileIsExecutable(path string) bool {
 	return err == nil && (stat.Mode()&0100 == 0100)
 }
 
+// Checks, if executable could be found on path or directly, if it contains slash.
+func isExecutableInPath(name, path string) bool {
+	if strings.Contains(name, "/") {
+		return fileIsExecutable(name)
+	}
+	for _, dir := range filepath.SplitList(path) {
+		if dir != "" && fileIsExecutable(filepath.Join(dir, name)) {
+			return true
+		}
+	}
+	return false
+}
+
 // Sanitize value.
 func sanitizeValue(value, defaultValue string) string {
 	if value == "" {
@@ -232,7 +290,22 @@ func cmdAsUser(usr *sysuser, name string, arg ...string) *exec.Cmd {
 		name = nameArgs[0]
 		arg = append(nameArgs[1:], arg...)
 	}
-	cmd := exec.Command(name, arg...)
+	return cmdArgsAsUser(usr, append([]string{name}, arg...))
+}
+
+// Checks, if file is owned by root and is not writable by group or others.
+func isProtectedFile(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && stat.Uid == 0 && info.Mode().Perm()&0022 == 0
+}
+
+// Prepares *exec.Cmd from already parsed arguments to be started as sysuser.
+func cmdArgsAsUser(usr *sysuser, args []string) *exec.Cmd {
+	cmd := exec.Command(args[0], args[1:]...)
 	cmd.Env = usr.environ()
 	cmd.SysProcAttr = &syscall.SysProcAttr{}
 	cmd.SysProcAttr.Credential = &syscall.Credential{Uid: usr.uidu32(), Gid: usr.gidu32(), Groups: usr.gidsu32}
@@ -309,6 +382,17 @@ func contains(array []string, values ...string) bool {
 	return false
 }
 
+// Parses comma separated list of values, blank values are skipped.
+func parseList(value string) []string {
+	var result []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
 // Parse boolean values.
 func parseBool(strBool, defaultValue string) bool {
 	val, err := strconv.ParseBool(sanitizeValue(strBool, defaultValue))
@@ -71,3 +71,61 @@ func setTerminalEcho(fd uintptr, status bool) error {
 	}
 	return nil
 }
+
+// Switches terminal into non-canonical mode without echo and returns its previous state
+func setTerminalRaw(fd uintptr) (*syscall.Termios, error) {
+	var termios = &syscall.Termios{}
+
+	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS, uintptr(unsafe.Pointer(termios))); err != 0 {
+		return nil, err
+	}
+	previous := *termios
+
+	termios.Lflag &^= syscall.ECHO | syscall.ICANON
+	termios.Cc[syscall.VMIN] = 1
+	termios.Cc[syscall.VTIME] = 0
+
+	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TCSETS), uintptr(unsafe.Pointer(termios))); err != 0 {
+		return nil, err
+	}
+	return &previous, nil
+}
+
+// Restores previously stored terminal state
+func restoreTerminal(fd uintptr, termios *syscall.Termios) error {
+	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TCSETS), uintptr(unsafe.Pointer(termios))); err != 0 {
+		return err
+	}
+	return nil
+}
+
+// Checks, if file descriptor is terminal
+func isTerminal(fd uintptr) bool {
+	var termios = &syscall.Termios{}
+	_, _, err := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS, uintptr(unsafe.Pointer(termios)))
+	return err == 0
+}
+
+// Gets width of terminal in columns, 0 is returned if width could not be detected
+func getTerminalWidth(fd uintptr) int {
+	var ws = &struct{ row, col, xpixel, ypixel uint16 }{}
+	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(ws))); err != 0 {
+		return 0
+	}
+	return int(ws.col)
+}
+
+// Releases controlling terminal of current process
+func releaseControllingTerminal(fd uintptr) error {
+	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCNOTTY, 0); err != 0 && err != syscall.ENOTTY {
+		return err
+	}
+	return nil
+}
+
+// Checks, if terminal is controlling terminal of any session
+func hasTerminalSession(fd uintptr) bool {
+	var sid int32
+	_, _, err := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGSID, uintptr(unsafe.Pointer(&sid)))
+	return err == 0
+}
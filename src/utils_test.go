@@ -213,3 +213,61 @@ func TestParseExec(t *testing.T) {
 		t.Error("TestParseExec: unexpected length of parsed parts of executable")
 	}
 }
+
+func TestParseList(t *testing.T) {
+	if len(parseList("")) != 0 || len(parseList(" , ,")) != 0 {
+		t.Error("TestParseList: empty list was expected")
+	}
+
+	list := parseList("a, b c ,,d")
+	if len(list) != 3 || list[0] != "a" || list[1] != "b c" || list[2] != "d" {
+		t.Errorf("TestParseList: unexpected result %v", list)
+	}
+}
+
+func TestIsExecutableInPath(t *testing.T) {
+	if !isExecutableInPath("sh", "/nonexisting:/bin:/usr/bin") {
+		t.Error("TestIsExecutableInPath: sh should be found on path")
+	}
+
+	if !isExecutableInPath("/bin/sh", "") {
+		t.Error("TestIsExecutableInPath: absolute path should be checked directly")
+	}
+
+	if isExecutableInPath("sh", "/nonexisting") || isExecutableInPath("emptty-not-installed-session", "/bin:/usr/bin") {
+		t.Error("TestIsExecutableInPath: executable should not be found")
+	}
+
+	if isExecutableInPath(getTestingPath("desktop-entries/spec.desktop"), "") {
+		t.Error("TestIsExecutableInPath: non-executable file should not be found")
+	}
+}
+
+func TestParseSectionHeader(t *testing.T) {
+	if name, ok := parseSectionHeader("[work]"); !ok || name != "work" {
+		t.Error("TestParseSectionHeader: section header was not parsed")
+	}
+
+	for _, line := range []string{"[]", "[ -f ~/.profile ]", "KEY=[value]", "[a=b]"} {
+		if _, ok := parseSectionHeader(line); ok {
+			t.Errorf("TestParseSectionHeader: '%s' should not be handled as section header", line)
+		}
+	}
+}
+
+func TestIsProtectedFile(t *testing.T) {
+	if !isProtectedFile("/etc/passwd") {
+		t.Error("TestIsProtectedFile: /etc/passwd should be protected")
+	}
+
+	path := t.TempDir() + "/writable"
+	os.WriteFile(path, []byte{}, 0666)
+	os.Chmod(path, 0666)
+	if isProtectedFile(path) {
+		t.Error("TestIsProtectedFile: writable file should not be protected")
+	}
+
+	if isProtectedFile(path + "-missing") {
+		t.Error("TestIsProtectedFile: missing file should not be protected")
+	}
+}